@@ -0,0 +1,74 @@
+package gpx
+
+import (
+	"math"
+	"sort"
+)
+
+// ComputeBounds returns the bounds of every waypoint, route point and track point
+// in the file, or nil if there are none.
+// When the points are closer to each other across the antimeridian, the bounds cross it
+// as well and MinimumLongitude is greater than MaximumLongitude.
+func (g *GPX) ComputeBounds() *Bounds {
+	latitudes := []float64{}
+	longitudes := []float64{}
+	add := func(p Position) {
+		lat, lon := p.LatLon()
+		latitudes = append(latitudes, float64(lat))
+		longitudes = append(longitudes, float64(lon))
+	}
+
+	for i := range g.Waypoints {
+		add(&g.Waypoints[i])
+	}
+	for i := range g.Routes {
+		for j := range g.Routes[i].RoutePoints {
+			add(&g.Routes[i].RoutePoints[j])
+		}
+	}
+	for i := range g.Tracks {
+		for j := range g.Tracks[i].TrackSegments {
+			for k := range g.Tracks[i].TrackSegments[j].TrackPoint {
+				add(&g.Tracks[i].TrackSegments[j].TrackPoint[k])
+			}
+		}
+	}
+
+	return boundsOf(latitudes, longitudes)
+}
+
+// boundsOf returns the bounds of a set of coordinates, taking the antimeridian into account
+func boundsOf(latitudes, longitudes []float64) *Bounds {
+	if len(latitudes) == 0 {
+		return nil
+	}
+
+	bounds := &Bounds{
+		MinimumLatitude: Latitude(latitudes[0]),
+		MaximumLatitude: Latitude(latitudes[0]),
+	}
+	for _, lat := range latitudes {
+		bounds.MinimumLatitude = Latitude(math.Min(float64(bounds.MinimumLatitude), lat))
+		bounds.MaximumLatitude = Latitude(math.Max(float64(bounds.MaximumLatitude), lat))
+	}
+
+	// The smallest longitude range covering every point is everything except the largest
+	// gap between two neighbouring longitudes, going around the globe.
+	sorted := make([]float64, len(longitudes))
+	copy(sorted, longitudes)
+	sort.Float64s(sorted)
+
+	last := len(sorted) - 1
+	bounds.MinimumLongitude = Longitude(sorted[0])
+	bounds.MaximumLongitude = Longitude(sorted[last])
+	largestGap := sorted[0] + 360 - sorted[last]
+	for i := 1; i <= last; i++ {
+		if gap := sorted[i] - sorted[i-1]; gap > largestGap {
+			largestGap = gap
+			bounds.MinimumLongitude = Longitude(sorted[i])
+			bounds.MaximumLongitude = Longitude(sorted[i-1])
+		}
+	}
+
+	return bounds
+}
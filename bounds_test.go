@@ -0,0 +1,63 @@
+package gpx_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_ComputeBounds(t *testing.T) {
+	g := gpx.GPX{
+		Waypoints: []gpx.WayPoint{{Latitude: 10, Longitude: 20}},
+		Routes:    []gpx.Route{{RoutePoints: []gpx.RoutePoint{{Latitude: -5, Longitude: 25}}}},
+		Tracks: []gpx.Track{{TrackSegments: []gpx.TrackSegment{{TrackPoint: []gpx.TrackPoint{
+			{Latitude: 12, Longitude: 15},
+			{Latitude: 11, Longitude: 30},
+		}}}}},
+	}
+
+	bounds := g.ComputeBounds()
+	require.NotNil(t, bounds)
+	assert.Equal(t, gpx.Latitude(-5), bounds.MinimumLatitude)
+	assert.Equal(t, gpx.Latitude(12), bounds.MaximumLatitude)
+	assert.Equal(t, gpx.Longitude(15), bounds.MinimumLongitude)
+	assert.Equal(t, gpx.Longitude(30), bounds.MaximumLongitude)
+
+	assert.Nil(t, (&gpx.GPX{}).ComputeBounds())
+}
+
+func Test_ComputeBoundsAcrossAntimeridian(t *testing.T) {
+	g := gpx.GPX{Waypoints: []gpx.WayPoint{
+		{Latitude: -17, Longitude: 178.5},
+		{Latitude: -18, Longitude: -179.5},
+		{Latitude: -16, Longitude: 179.9},
+	}}
+
+	bounds := g.ComputeBounds()
+	assert.Equal(t, gpx.Longitude(178.5), bounds.MinimumLongitude)
+	assert.Equal(t, gpx.Longitude(-179.5), bounds.MaximumLongitude)
+}
+
+func Test_EncodeRefreshMetadata(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/mapbox.gpx")
+	require.Nil(t, err)
+	original := g.Metadata.Timestamp
+
+	buf := bytes.Buffer{}
+	encoder := gpx.NewEncoder(&buf, gpx.RefreshMetadata())
+	require.Nil(t, encoder.Encode(g))
+	assert.Equal(t, original, g.Metadata.Timestamp)
+	assert.Nil(t, g.Metadata.Bounds)
+
+	p := gpx.GPX{}
+	require.Nil(t, gpx.Parse(buf.Bytes(), &p))
+	require.NotNil(t, p.Metadata.Bounds)
+	bounds := g.ComputeBounds()
+	assert.Equal(t, bounds.MinimumLatitude, p.Metadata.Bounds.MinimumLatitude)
+	assert.Equal(t, bounds.MaximumLongitude, p.Metadata.Bounds.MaximumLongitude)
+	assert.WithinDuration(t, time.Now(), p.Metadata.Timestamp.Time, time.Minute)
+}
@@ -0,0 +1,123 @@
+package gpx
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"iter"
+	"path"
+	"strings"
+)
+
+// gzipMagic starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// isGzip tells if data is compressed with gzip
+func isGzip(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic)
+}
+
+// isGzipName tells if a file should be written compressed with gzip
+func isGzipName(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".gz")
+}
+
+// gzipData compresses data with gzip
+func gzipData(data []byte) ([]byte, error) {
+	buf := bytes.Buffer{}
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress returns a reader of the uncompressed content when data is compressed
+// with gzip, and of data itself otherwise
+func decompress(data []byte) (io.Reader, error) {
+	if !isGzip(data) {
+		return bytes.NewReader(data), nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, &IOError{Err: err}
+	}
+	return reader, nil
+}
+
+// decompressReader returns a reader of the uncompressed content of r when it is
+// compressed with gzip, and of r itself otherwise
+func decompressReader(r io.Reader) (io.Reader, error) {
+	reader := bufio.NewReader(r)
+	magic, err := reader.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, &IOError{Err: err}
+	}
+	if !isGzip(magic) {
+		return reader, nil
+	}
+
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, &IOError{Err: err}
+	}
+	return gz, nil
+}
+
+// ZipFile is a GPX file read from a zip archive
+type ZipFile struct {
+	// Name is the path of the file inside the archive
+	Name string
+	GPX  *GPX
+}
+
+// ZipFiles decodes every .gpx and .gpx.gz file inside a zip archive, in the order they
+// are stored. Directories, other files and the metadata macOS adds to archives are
+// skipped. A file which can't be read is yielded with its error, and iteration goes on
+// with the next one unless the caller stops.
+func ZipFiles(archive *zip.Reader, opts ...ReadOption) iter.Seq2[ZipFile, error] {
+	return func(yield func(ZipFile, error) bool) {
+		for _, file := range archive.File {
+			if !isGPXName(file.Name) {
+				continue
+			}
+
+			zipFile := ZipFile{Name: file.Name}
+			g, err := parseZipFile(file, opts)
+			if err == nil {
+				zipFile.GPX = g
+			}
+			if !yield(zipFile, err) {
+				return
+			}
+		}
+	}
+}
+
+// isGPXName tells if a file in an archive holds a GPX document
+func isGPXName(name string) bool {
+	if strings.HasSuffix(name, "/") || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._") {
+		return false
+	}
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".gpx") || strings.HasSuffix(lower, ".gpx.gz")
+}
+
+func parseZipFile(file *zip.File, opts []ReadOption) (*GPX, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, &IOError{Err: err}
+	}
+	defer reader.Close()
+
+	g, err := ParseReader(reader, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
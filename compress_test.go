@@ -0,0 +1,86 @@
+package gpx_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_WriteAndParseGzip(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/mapbox.gpx")
+	require.Nil(t, err)
+
+	name := filepath.Join(t.TempDir(), "compressed.gpx.gz")
+	require.Nil(t, gpx.Write(g, name))
+
+	data, err := os.ReadFile(name)
+	require.Nil(t, err)
+	_, err = gzip.NewReader(bytes.NewReader(data))
+	require.Nil(t, err)
+
+	p, err := gpx.ParseFile(name)
+	require.Nil(t, err)
+	assert.Equal(t, g.Tracks, p.Tracks)
+}
+
+func Test_ZipFiles(t *testing.T) {
+	plain, err := os.ReadFile("./samples/mapbox.gpx")
+	require.Nil(t, err)
+
+	compressed := bytes.Buffer{}
+	writer := gzip.NewWriter(&compressed)
+	_, err = writer.Write(plain)
+	require.Nil(t, err)
+	require.Nil(t, writer.Close())
+
+	archive := bytes.Buffer{}
+	zipWriter := zip.NewWriter(&archive)
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{"rides/mapbox.gpx", plain},
+		{"readme.txt", []byte("not a track")},
+		{"__MACOSX/rides/._mapbox.gpx", []byte("resource fork")},
+		{"rides/broken.GPX", []byte("<gpx><trk></gpx>")},
+		{"rides/archived.gpx.gz", compressed.Bytes()},
+	}
+	for _, entry := range entries {
+		w, err := zipWriter.Create(entry.name)
+		require.Nil(t, err)
+		_, err = w.Write(entry.data)
+		require.Nil(t, err)
+	}
+	require.Nil(t, zipWriter.Close())
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	require.Nil(t, err)
+
+	names := []string{}
+	for file, err := range gpx.ZipFiles(reader) {
+		names = append(names, file.Name)
+		if file.Name == "rides/broken.GPX" {
+			assert.True(t, errors.Is(err, gpx.ErrSyntax))
+			assert.Nil(t, file.GPX)
+			continue
+		}
+		require.Nil(t, err)
+		assert.Equal(t, "Untitled", file.GPX.Tracks[0].Name)
+	}
+	assert.Equal(t, []string{"rides/mapbox.gpx", "rides/broken.GPX", "rides/archived.gpx.gz"}, names)
+
+	// Limits stop entries while they are decompressed
+	for file, err := range gpx.ZipFiles(reader, gpx.MaxBytes(512)) {
+		if file.Name != "rides/broken.GPX" {
+			assert.True(t, errors.Is(err, gpx.ErrLimitExceeded), file.Name)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrEmptyCourse is returned when writing a course without any point
+var ErrEmptyCourse = errors.New("gpx: course has no points")
+
+// CourseSpeed is the speed at which courses are taken to be ridden to time their
+// points, when some of them have no timestamp
+const CourseSpeed MetresPerSecond = 20 / 3.6
+
+// fitMaxString is the largest size of a string field, including the null byte which
+// ends it
+const fitMaxString = 255
+
+// Values written in the file_id and event messages of courses
+const (
+	fitFileCourse          = 6
+	fitManufacturerDevelop = 255
+
+	fitEvent               = 21
+	fitEventTimer          = 0
+	fitEventStart          = 0
+	fitEventStopDisableAll = 9
+)
+
+// WriteFITCourse writes a track as a FIT course file which can be copied to a Garmin
+// device, with the waypoints as course points, see WriteFITRouteCourse
+func WriteFITCourse(w io.Writer, t *Track, waypoints []WayPoint, opts ...WriteOption) error {
+	points := []courseRecord{}
+	for i := range t.TrackSegments {
+		for j := range t.TrackSegments[i].TrackPoint {
+			p := &t.TrackSegments[i].TrackPoint[j]
+			points = append(points, courseRecord{point: Point{Latitude: p.Latitude, Longitude: p.Longitude}, elevation: p.Elevation, time: p.Timestamp.Time})
+		}
+	}
+	return writeFITCourse(w, t.Name, t.Type, points, waypoints, newWriteOptions(opts))
+}
+
+// WriteFITRouteCourse writes a route as a FIT course file which can be copied to a
+// Garmin device. The course has a single lap and timer start and stop events around a
+// record for every point and a course point for every waypoint, placed at the distance
+// of the record closest to it and typed after the waypoint type when it is a FIT course
+// point type like "summit".
+// The type of the route is used as the sport when it is a FIT sport like "cycling".
+// Points are timed at CourseSpeed from the time of the first point, or from the current
+// time given by the Clock option, unless they all have a timestamp.
+func WriteFITRouteCourse(w io.Writer, r *Route, waypoints []WayPoint, opts ...WriteOption) error {
+	points := []courseRecord{}
+	for i := range r.RoutePoints {
+		p := &r.RoutePoints[i]
+		points = append(points, courseRecord{point: Point{Latitude: p.Latitude, Longitude: p.Longitude}, elevation: p.Elevation, time: p.Timestamp.Time})
+	}
+	return writeFITCourse(w, r.Name, r.Type, points, waypoints, newWriteOptions(opts))
+}
+
+// courseRecord is a point of a course with its distance from the start
+type courseRecord struct {
+	point     Point
+	elevation *float64
+	time      time.Time
+	distance  float64
+}
+
+func writeFITCourse(w io.Writer, name, sport string, records []courseRecord, waypoints []WayPoint, o writeOptions) error {
+	if len(records) == 0 {
+		return ErrEmptyCourse
+	}
+
+	timed := true
+	for i := range records {
+		if i > 0 {
+			records[i].distance = records[i-1].distance + float64(Distance(records[i-1].point, records[i].point))
+		}
+		timed = timed && !records[i].time.IsZero()
+	}
+	if !timed {
+		start := records[0].time
+		if start.IsZero() {
+			start = o.now().UTC().Truncate(time.Second)
+		}
+		for i := range records {
+			records[i].time = start.Add(time.Duration(records[i].distance / float64(CourseSpeed) * float64(time.Second)))
+		}
+	}
+	first, last := records[0], records[len(records)-1]
+
+	e := fitEncoder{locals: map[uint16]byte{}}
+	e.message(fitFileID,
+		fitEnum(0, fitFileCourse),
+		fitUint16(1, fitManufacturerDevelop),
+		fitUint16(2, 0),
+		fitUint32(4, fitTimeValue(first.time)),
+	)
+	e.message(fitCourse,
+		fitEnum(4, byte(fitValueOf(fitSports, sport))),
+		fitString(5, name, fitStringSize(name)),
+	)
+	elapsed := uint32(last.time.Sub(first.time).Milliseconds())
+	e.message(fitLap,
+		fitUint32(fitTimestamp, fitTimeValue(last.time)),
+		fitUint32(2, fitTimeValue(first.time)),
+		fitSint32(3, toSemicircles(float64(first.point.Latitude))),
+		fitSint32(4, toSemicircles(float64(first.point.Longitude))),
+		fitSint32(5, toSemicircles(float64(last.point.Latitude))),
+		fitSint32(6, toSemicircles(float64(last.point.Longitude))),
+		fitUint32(7, elapsed),
+		fitUint32(8, elapsed),
+		fitUint32(9, uint32(math.Round(last.distance*100))),
+	)
+	e.message(fitEvent,
+		fitUint32(fitTimestamp, fitTimeValue(first.time)),
+		fitEnum(0, fitEventTimer),
+		fitEnum(1, fitEventStart),
+	)
+	for _, record := range records {
+		e.message(fitRecord,
+			fitUint32(fitTimestamp, fitTimeValue(record.time)),
+			fitSint32(0, toSemicircles(float64(record.point.Latitude))),
+			fitSint32(1, toSemicircles(float64(record.point.Longitude))),
+			fitUint32(5, uint32(math.Round(record.distance*100))),
+			fitUint32(78, fitAltitudeValue(record.elevation)),
+		)
+	}
+
+	coursePoints := make([]courseRecord, len(waypoints))
+	for i := range waypoints {
+		coursePoints[i] = closestRecord(records, &waypoints[i])
+	}
+	order := make([]int, len(waypoints))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return coursePoints[order[i]].distance < coursePoints[order[j]].distance
+	})
+	names := make([]string, len(waypoints))
+	for i := range waypoints {
+		names[i] = waypoints[i].Name
+	}
+	nameSize := fitStringSize(names...)
+	for index, i := range order {
+		waypoint := &waypoints[i]
+		e.message(fitCoursePoint,
+			fitUint16(254, uint16(index)),
+			fitUint32(1, fitTimeValue(coursePoints[i].time)),
+			fitSint32(2, toSemicircles(float64(waypoint.Latitude))),
+			fitSint32(3, toSemicircles(float64(waypoint.Longitude))),
+			fitUint32(4, uint32(math.Round(coursePoints[i].distance*100))),
+			fitEnum(5, byte(fitValueOf(fitCoursePointTypes, waypoint.Type))),
+			fitString(6, waypoint.Name, nameSize),
+		)
+	}
+	e.message(fitEvent,
+		fitUint32(fitTimestamp, fitTimeValue(last.time)),
+		fitEnum(0, fitEventTimer),
+		fitEnum(1, fitEventStopDisableAll),
+	)
+
+	_, err := w.Write(e.file())
+	return err
+}
+
+// closestRecord returns the record closest to a waypoint
+func closestRecord(records []courseRecord, waypoint *WayPoint) courseRecord {
+	closest, distance := records[0], math.Inf(1)
+	for _, record := range records {
+		if d := float64(Distance(record.point, waypoint)); d < distance {
+			closest, distance = record, d
+		}
+	}
+	return closest
+}
+
+// fitEncoder writes messages, defining each message type the first time it is used
+type fitEncoder struct {
+	data   bytes.Buffer
+	locals map[uint16]byte
+}
+
+// message writes a data message, after its definition when it is the first one of its
+// type. Every message of a type must have the same fields.
+func (e *fitEncoder) message(global uint16, fields ...fitField) {
+	local, ok := e.locals[global]
+	if !ok {
+		local = byte(len(e.locals))
+		e.locals[global] = local
+
+		e.data.Write([]byte{0x40 | local, 0, 0})
+		e.data.Write(binary.LittleEndian.AppendUint16(nil, global))
+		e.data.WriteByte(byte(len(fields)))
+		for _, field := range fields {
+			e.data.Write([]byte{field.number, byte(len(field.data)), field.baseType})
+		}
+	}
+
+	e.data.WriteByte(local)
+	for _, field := range fields {
+		e.data.Write(field.data)
+	}
+}
+
+// file returns the complete file, with its header and CRCs
+func (e *fitEncoder) file() []byte {
+	header := []byte{14, 0x10}
+	header = binary.LittleEndian.AppendUint16(header, 2132)
+	header = binary.LittleEndian.AppendUint32(header, uint32(e.data.Len()))
+	header = append(header, ".FIT"...)
+	header = binary.LittleEndian.AppendUint16(header, fitCRC(0, header))
+
+	file := append(header, e.data.Bytes()...)
+	return binary.LittleEndian.AppendUint16(file, fitCRC(0, file))
+}
+
+func fitEnum(number, value byte) fitField {
+	return fitField{number: number, baseType: 0x00, data: []byte{value}}
+}
+
+func fitUint16(number byte, value uint16) fitField {
+	return fitField{number: number, baseType: 0x84, data: binary.LittleEndian.AppendUint16(nil, value)}
+}
+
+func fitUint32(number byte, value uint32) fitField {
+	return fitField{number: number, baseType: 0x86, data: binary.LittleEndian.AppendUint32(nil, value)}
+}
+
+func fitSint32(number byte, value int32) fitField {
+	return fitField{number: number, baseType: 0x85, data: binary.LittleEndian.AppendUint32(nil, uint32(value))}
+}
+
+// fitString returns a string field of a fixed size, truncated to fit with its null byte
+// without cutting a character in two
+func fitString(number byte, value string, size int) fitField {
+	for len(value) > size-1 {
+		_, last := utf8.DecodeLastRuneInString(value)
+		value = value[:len(value)-last]
+	}
+	data := make([]byte, size)
+	copy(data, value)
+	return fitField{number: number, baseType: 0x07, data: data}
+}
+
+// fitStringSize returns the size of a string field which fits the longest of values,
+// up to the largest size of a field
+func fitStringSize(values ...string) int {
+	size := 1
+	for _, value := range values {
+		size = max(size, len(value)+1)
+	}
+	return min(size, fitMaxString)
+}
+
+// fitAltitudeValue converts an elevation to a FIT altitude, which has a scale of 5 and
+// an offset of 500 m, clamped to the values a uint32 can hold besides the invalid one.
+// A missing elevation is written as the invalid value.
+func fitAltitudeValue(elevation *float64) uint32 {
+	if elevation == nil {
+		return math.MaxUint32
+	}
+	return uint32(max(0, min(math.Round((*elevation+500)*5), math.MaxUint32-1)))
+}
+
+// fitTimeValue converts a time to a FIT timestamp
+func fitTimeValue(t time.Time) uint32 {
+	return uint32(t.Sub(fitEpoch) / time.Second)
+}
+
+// toSemicircles converts degrees to semicircles. Angles are normalised from -180 to
+// 180 first, and those which round to 180 wrap to -180 so that they don't end up as the
+// invalid value of sint32.
+func toSemicircles(degrees float64) int32 {
+	return int32(int64(math.Round(normaliseLongitude(degrees) * (1 << 31) / 180)))
+}
+
+// fitValueOf returns the value of a name of a FIT enum, or 0 for names which aren't
+// known, which is generic for sports and course point types
+func fitValueOf(names []string, name string) int {
+	for i := range names {
+		if names[i] == name {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+package gpx_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_WriteFITCourse(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	track := gpx.Track{Name: "Sunday morning ride", Type: "cycling", TrackSegments: []gpx.TrackSegment{
+		{TrackPoint: []gpx.TrackPoint{
+			trackPoint(47.6, -122.33, 100, start),
+			trackPoint(47.601, -122.33, 110, start.Add(20*time.Second)),
+		}},
+		{TrackPoint: []gpx.TrackPoint{
+			trackPoint(47.602, -122.33, 105.4, start.Add(40*time.Second)),
+			trackPoint(47.603, -122.33, -600, start.Add(60*time.Second)),
+		}},
+	}}
+	waypoints := []gpx.WayPoint{
+		{Latitude: 47.6021, Longitude: -122.3301, Name: "Café au lait du matin", Type: "food"},
+		{Latitude: 47.6009, Longitude: -122.3299, Name: "Top", Type: "summit"},
+	}
+
+	buf := bytes.Buffer{}
+	require.Nil(t, gpx.WriteFITCourse(&buf, &track, waypoints))
+	assert.Equal(t, ".FIT", string(buf.Bytes()[8:12]))
+	// The records are between timer events, defined after the file_id, course and lap
+	assert.True(t, bytes.Contains(buf.Bytes(), []byte{0x43, 0, 0, 21, 0, 3, 253, 4, 0x86}))
+
+	g, err := gpx.ParseFIT(&buf)
+	require.Nil(t, err)
+	require.Len(t, g.Tracks, 1)
+	assert.Equal(t, "Sunday morning ride", g.Tracks[0].Name)
+	assert.Equal(t, "cycling", g.Tracks[0].Type)
+	require.Len(t, g.Tracks[0].TrackSegments, 1)
+	points := g.Tracks[0].TrackSegments[0].TrackPoint
+	require.Len(t, points, 4)
+	assert.InDelta(t, 47.601, float64(points[1].Latitude), 1e-7)
+	assert.InDelta(t, -122.33, float64(points[1].Longitude), 1e-7)
+	assert.InDelta(t, 105.4, *points[2].Elevation, 1e-9)
+	assert.Equal(t, start.Add(40*time.Second), points[2].Timestamp.Time)
+	assert.InDelta(t, -500, *points[3].Elevation, 1e-9)
+
+	require.Len(t, g.Waypoints, 2)
+	assert.Equal(t, "Top", g.Waypoints[0].Name)
+	assert.Equal(t, "summit", g.Waypoints[0].Type)
+	assert.Equal(t, start.Add(20*time.Second), g.Waypoints[0].Timestamp.Time)
+	assert.Equal(t, "Café au lait du matin", g.Waypoints[1].Name)
+	assert.Equal(t, "food", g.Waypoints[1].Type)
+}
+
+func Test_WriteFITRouteCourse(t *testing.T) {
+	route := gpx.Route{Name: "To the lake", RoutePoints: []gpx.RoutePoint{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 0.01},
+		{Latitude: 0, Longitude: 179.9999999999},
+	}}
+
+	buf := bytes.Buffer{}
+	require.Nil(t, gpx.WriteFITRouteCourse(&buf, &route, nil))
+
+	g, err := gpx.ParseFIT(&buf)
+	require.Nil(t, err)
+	assert.Equal(t, "generic", g.Tracks[0].Type)
+	points := g.Tracks[0].TrackSegments[0].TrackPoint
+	require.Len(t, points, 3)
+	assert.Equal(t, 200*time.Second, points[1].Timestamp.Sub(points[0].Timestamp.Time))
+	assert.InDelta(t, -180, float64(points[2].Longitude), 1e-6)
+
+	// Untimed courses start at the time given by the clock, and are written the same
+	// way every time
+	now := time.Date(2021, 6, 1, 8, 0, 0, 500, time.UTC)
+	clock := gpx.Clock(func() time.Time { return now })
+	first, second := bytes.Buffer{}, bytes.Buffer{}
+	require.Nil(t, gpx.WriteFITRouteCourse(&first, &route, nil, clock))
+	require.Nil(t, gpx.WriteFITRouteCourse(&second, &route, nil, clock))
+	assert.Equal(t, first.Bytes(), second.Bytes())
+	g, err = gpx.ParseFIT(&first)
+	require.Nil(t, err)
+	assert.Equal(t, now.Truncate(time.Second), g.Tracks[0].TrackSegments[0].TrackPoint[0].Timestamp.Time)
+
+	// Names are only cut at the largest size of a FIT string
+	buf.Reset()
+	long := strings.Repeat("é", 200)
+	require.Nil(t, gpx.WriteFITRouteCourse(&buf, &gpx.Route{Name: long, RoutePoints: route.RoutePoints}, nil))
+	g, err = gpx.ParseFIT(&buf)
+	require.Nil(t, err)
+	assert.Equal(t, long[:254], g.Tracks[0].Name)
+
+	assert.True(t, errors.Is(gpx.WriteFITRouteCourse(&buf, &gpx.Route{}, nil), gpx.ErrEmptyCourse))
+}
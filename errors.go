@@ -0,0 +1,131 @@
+package gpx
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	xml "github.com/Zauberstuhl/go-xml"
+)
+
+// Errors returned while reading a document can be matched with errors.Is against these,
+// or with errors.As against the types below for the details
+var (
+	ErrSyntax             = errors.New("gpx: syntax error")
+	ErrUnsupportedVersion = errors.New("gpx: unsupported version")
+	ErrInvalidCoordinate  = errors.New("gpx: invalid coordinate")
+	ErrInvalidValue       = errors.New("gpx: invalid value")
+	ErrIO                 = errors.New("gpx: I/O error")
+)
+
+// SyntaxError is returned when the document isn't well formed XML, or valid JSON for
+// GeoJSON
+type SyntaxError struct {
+	// Offset is the number of bytes read when the error was found, and Line and Column
+	// its position starting at 1
+	Offset  int64
+	Line    int
+	Column  int
+	Message string
+	Err     error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("gpx: syntax error on line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+func (e *SyntaxError) Is(target error) bool {
+	return target == ErrSyntax
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedVersionError is returned for documents which are neither GPX 1.1 nor 1.0
+type UnsupportedVersionError struct {
+	Version string
+}
+
+func (e *UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("gpx: unsupported version %q, expected 1.1 or 1.0", e.Version)
+}
+
+func (e *UnsupportedVersionError) Is(target error) bool {
+	return target == ErrUnsupportedVersion
+}
+
+// ValueError is returned when the text of an element or the value of an attribute can't
+// be read into its field, like an elevation which isn't a number
+type ValueError struct {
+	// Path is the path of the element, with /@name appended for attributes, and Line
+	// and Column the position of its start tag
+	Path   string
+	Attr   string
+	Value  string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ValueError) Error() string {
+	return fmt.Sprintf("gpx: invalid value %q for %s on line %d, column %d: %v", e.Value, e.Path, e.Line, e.Column, e.Err)
+}
+
+func (e *ValueError) Is(target error) bool {
+	return target == ErrInvalidValue
+}
+
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+// CoordinateError is returned when a latitude or longitude isn't a number. Coordinates
+// out of range are only reported by Validate.
+type CoordinateError ValueError
+
+func (e *CoordinateError) Error() string {
+	return fmt.Sprintf("gpx: invalid coordinate %q for %s on line %d, column %d: %v", e.Value, e.Path, e.Line, e.Column, e.Err)
+}
+
+func (e *CoordinateError) Is(target error) bool {
+	return target == ErrInvalidCoordinate || target == ErrInvalidValue
+}
+
+func (e *CoordinateError) Unwrap() error {
+	return e.Err
+}
+
+// IOError is returned when the document can't be read, wrapping the error of the
+// underlying file or reader
+type IOError struct {
+	Err error
+}
+
+func (e *IOError) Error() string {
+	return "gpx: " + e.Err.Error()
+}
+
+func (e *IOError) Is(target error) bool {
+	return target == ErrIO
+}
+
+func (e *IOError) Unwrap() error {
+	return e.Err
+}
+
+// checkVersion returns an UnsupportedVersionError when the root element is from
+// neither GPX 1.1 nor 1.0. Documents without a version are read as GPX 1.1.
+func checkVersion(start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local != "version" {
+			continue
+		}
+		switch version := strings.TrimSpace(attr.Value); version {
+		case "", "1.1", "1.0":
+		default:
+			return &UnsupportedVersionError{Version: version}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package gpx_test
+
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_ValueError(t *testing.T) {
+	_, err := gpx.ParseFile("./samples/error.gpx")
+
+	valueErr := &gpx.ValueError{}
+	require.True(t, errors.As(err, &valueErr))
+	assert.Equal(t, "/gpx/trk/trkseg/trkpt/ele", valueErr.Path)
+	assert.Equal(t, "pointElement", valueErr.Value)
+	assert.Equal(t, 19, valueErr.Line)
+	assert.Equal(t, 17, valueErr.Column)
+
+	numberErr := &strconv.NumError{}
+	assert.True(t, errors.As(err, &numberErr))
+	assert.False(t, errors.Is(err, gpx.ErrInvalidCoordinate))
+}
+
+func Test_CoordinateError(t *testing.T) {
+	data := []byte(`<gpx version="1.1" creator="test">
+  <wpt lat="north" lon="10"/>
+</gpx>`)
+
+	err := gpx.Parse(data, &gpx.GPX{})
+	assert.True(t, errors.Is(err, gpx.ErrInvalidCoordinate))
+	assert.True(t, errors.Is(err, gpx.ErrInvalidValue))
+
+	coordinateErr := &gpx.CoordinateError{}
+	require.True(t, errors.As(err, &coordinateErr))
+	assert.Equal(t, "lat", coordinateErr.Attr)
+	assert.Equal(t, "north", coordinateErr.Value)
+	assert.Equal(t, "/gpx/wpt/@lat", coordinateErr.Path)
+	assert.Equal(t, 2, coordinateErr.Line)
+	assert.Equal(t, 3, coordinateErr.Column)
+}
+
+func Test_SyntaxError(t *testing.T) {
+	data := []byte(`<gpx version="1.1" creator="test">
+  <trk>
+    <name>broken</name>
+</gpx>`)
+
+	err := gpx.Parse(data, &gpx.GPX{})
+	assert.True(t, errors.Is(err, gpx.ErrSyntax))
+
+	syntaxErr := &gpx.SyntaxError{}
+	require.True(t, errors.As(err, &syntaxErr))
+	assert.Equal(t, 4, syntaxErr.Line)
+	assert.Equal(t, int64(len(data)), syntaxErr.Offset)
+	assert.Contains(t, syntaxErr.Error(), "line 4")
+
+	decoder := gpx.NewDecoder(strings.NewReader(string(data)))
+	for _, err := range decoder.Elements() {
+		if err != nil {
+			assert.True(t, errors.Is(err, gpx.ErrSyntax))
+		}
+	}
+}
+
+func Test_UnsupportedVersionError(t *testing.T) {
+	data := []byte(`<gpx version="2.0" creator="test"></gpx>`)
+
+	err := gpx.Parse(data, &gpx.GPX{})
+	assert.True(t, errors.Is(err, gpx.ErrUnsupportedVersion))
+
+	versionErr := &gpx.UnsupportedVersionError{}
+	require.True(t, errors.As(err, &versionErr))
+	assert.Equal(t, "2.0", versionErr.Version)
+}
+
+func Test_IOError(t *testing.T) {
+	_, err := gpx.ParseFile("./samples/missing.gpx")
+	assert.True(t, errors.Is(err, gpx.ErrIO))
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	failure := errors.New("connection reset")
+	decoder := gpx.NewDecoder(iotest.ErrReader(failure))
+	err = decoder.Decode(&gpx.GPX{})
+	assert.True(t, errors.Is(err, gpx.ErrIO))
+	assert.True(t, errors.Is(err, failure))
+}
@@ -0,0 +1,720 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// ErrInvalidFIT is matched by every FITError
+var ErrInvalidFIT = errors.New("gpx: invalid FIT file")
+
+// FITError is returned when a FIT file can't be decoded
+type FITError struct {
+	// Offset is the position in the file, in bytes, of the part which is invalid
+	Offset  int64
+	Message string
+}
+
+func (e *FITError) Error() string {
+	return fmt.Sprintf("gpx: invalid FIT file at byte %d: %s", e.Offset, e.Message)
+}
+
+func (e *FITError) Is(target error) bool {
+	return target == ErrInvalidFIT
+}
+
+// fitEpoch is the time FIT timestamps count seconds from
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// Global numbers of the FIT messages which are read
+const (
+	fitFileID      = 0
+	fitSession     = 18
+	fitLap         = 19
+	fitRecord      = 20
+	fitLocation    = 29
+	fitCourse      = 31
+	fitCoursePoint = 32
+)
+
+// fitTimestamp is the field number of the timestamp in every message which has one
+const fitTimestamp = 253
+
+// fitSports are the names of the sports of the FIT profile, used as track types
+var fitSports = []string{
+	"generic", "running", "cycling", "transition", "fitness_equipment", "swimming",
+	"basketball", "soccer", "tennis", "american_football", "training", "walking",
+	"cross_country_skiing", "alpine_skiing", "snowboarding", "rowing", "mountaineering",
+	"hiking", "multisport", "paddling", "flying", "e_biking",
+}
+
+// fitCoursePointTypes are the names of the types of course points of the FIT profile,
+// used as waypoint types
+var fitCoursePointTypes = []string{
+	"generic", "summit", "valley", "water", "food", "danger", "left", "right",
+	"straight", "first_aid", "fourth_category", "third_category", "second_category",
+	"first_category", "hors_category", "sprint", "left_fork", "right_fork",
+	"middle_fork", "slight_left", "sharp_left", "slight_right", "sharp_right",
+	"u_turn", "segment_start", "segment_end",
+}
+
+// fitCRCTable is used to compute the CRC of FIT files four bits at a time
+var fitCRCTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// fitCRC continues the CRC of a FIT file with data
+func fitCRC(crc uint16, data []byte) uint16 {
+	for _, b := range data {
+		tmp := fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[b&0xF]
+		tmp = fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[(b>>4)&0xF]
+	}
+	return crc
+}
+
+// ParseFITFile reads a FIT file, see ParseFIT
+func ParseFITFile(fileName string, opts ...ReadOption) (*GPX, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return &GPX{}, &IOError{Err: err}
+	}
+	defer file.Close()
+	return ParseFIT(file, opts...)
+}
+
+// ParseFIT reads a FIT activity, course or waypoints file, which can be compressed with
+// gzip, into a GPX document. Record messages become track points with their heart
+// rate, cadence, temperature and speed in TrackPointExtension and their power in
+// PowerInWatts. Records are split into a track for every session and a segment for
+// every lap, by the start time and the end time of the sessions and laps, which are
+// often written at the end of the file. Records are split by the order of the messages
+// instead when they or the laps and sessions have no time.
+// Course points and locations become waypoints. Records without a position are
+// skipped, as GPX points must have one.
+// Errors are a FITError for files which can't be decoded, an IOError, and with the
+// MaxBytes, MaxPoints and Context options a LimitError or the error of the context.
+func ParseFIT(r io.Reader, opts ...ReadOption) (*GPX, error) {
+	g := GPX{}
+	o := newReadOptions(opts)
+
+	input, err := decompressReader(r)
+	if err != nil {
+		return &g, err
+	}
+	if o.maxBytes > 0 {
+		input = io.LimitReader(input, o.maxBytes+1)
+	}
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return &g, &IOError{Err: err}
+	}
+	if o.maxBytes > 0 && int64(len(data)) > o.maxBytes {
+		return &g, &LimitError{Limit: "bytes", Max: o.maxBytes}
+	}
+
+	dec := fitDecoder{data: data, options: o, converter: fitConverter{gpx: &g}}
+	if err := dec.decode(); err != nil {
+		return &g, err
+	}
+	return &g, nil
+}
+
+// fitDecoder reads the messages of one or more chained FIT files
+type fitDecoder struct {
+	data    []byte
+	offset  int
+	options readOptions
+
+	definitions [16]*fitDefinition
+	// timestamp is the last timestamp read, which compressed timestamps are relative to
+	timestamp uint32
+	points    int
+
+	converter fitConverter
+}
+
+// fitDefinition describes the fields of the messages of a local message type
+type fitDefinition struct {
+	global    uint16
+	bigEndian bool
+	fields    []fitFieldDefinition
+	// developerSize is the size of the developer fields, which are skipped
+	developerSize int
+}
+
+type fitFieldDefinition struct {
+	number   byte
+	size     byte
+	baseType byte
+}
+
+// fitMessage is a data message with the raw value of each of its fields
+type fitMessage struct {
+	global    uint16
+	bigEndian bool
+	fields    []fitField
+}
+
+type fitField struct {
+	number   byte
+	baseType byte
+	data     []byte
+}
+
+// fail returns a FITError at an offset
+func (d *fitDecoder) fail(offset int, format string, args ...any) error {
+	return &FITError{Offset: int64(offset), Message: fmt.Sprintf(format, args...)}
+}
+
+// decode reads every file in the data, at least one
+func (d *fitDecoder) decode() error {
+	for {
+		if err := d.decodeFile(); err != nil {
+			return err
+		}
+		if len(d.data)-d.offset < 12 {
+			return nil
+		}
+	}
+}
+
+// decodeFile reads the header, the messages and the CRC of a file
+func (d *fitDecoder) decodeFile() error {
+	start := d.offset
+	header := d.data[start:]
+	if len(header) < 12 || header[0] < 12 || len(header) < int(header[0]) {
+		return d.fail(start, "file header is truncated")
+	}
+	headerSize := int(header[0])
+	if string(header[8:12]) != ".FIT" {
+		return d.fail(start+8, "missing .FIT signature")
+	}
+	if headerSize >= 14 {
+		crc := binary.LittleEndian.Uint16(header[12:14])
+		if crc != 0 && crc != fitCRC(0, header[:12]) {
+			return d.fail(start+12, "header CRC mismatch")
+		}
+	}
+
+	end := start + headerSize + int(binary.LittleEndian.Uint32(header[4:8]))
+	if end+2 > len(d.data) {
+		return d.fail(start+4, "data size %d goes past the end of the file", end-start-headerSize)
+	}
+	if crc := binary.LittleEndian.Uint16(d.data[end:]); crc != fitCRC(0, d.data[start:end]) {
+		return d.fail(end, "file CRC mismatch")
+	}
+
+	d.definitions = [16]*fitDefinition{}
+	d.offset = start + headerSize
+	for d.offset < end {
+		if err := d.decodeRecord(end); err != nil {
+			return err
+		}
+	}
+	d.offset = end + 2
+	d.converter.endFile()
+	return nil
+}
+
+// decodeRecord reads a definition or a data message
+func (d *fitDecoder) decodeRecord(end int) error {
+	if ctx := d.options.ctx; ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	header := d.data[d.offset]
+	start := d.offset
+	d.offset++
+
+	var timestamp *uint32
+	var local byte
+	switch {
+	case header&0x80 != 0:
+		// Compressed timestamp header, with an offset from the last timestamp
+		local = (header >> 5) & 0x3
+		offset := uint32(header & 0x1F)
+		t := (d.timestamp &^ 0x1F) + offset
+		if offset < d.timestamp&0x1F {
+			t += 0x20
+		}
+		timestamp = &t
+	case header&0x40 != 0:
+		return d.decodeDefinition(header, end)
+	default:
+		local = header & 0x0F
+	}
+
+	definition := d.definitions[local]
+	if definition == nil {
+		return d.fail(start, "data message of undefined local type %d", local)
+	}
+
+	message := fitMessage{global: definition.global, bigEndian: definition.bigEndian}
+	for _, field := range definition.fields {
+		if d.offset+int(field.size) > end {
+			return d.fail(d.offset, "message goes past the end of the data")
+		}
+		message.fields = append(message.fields, fitField{
+			number:   field.number,
+			baseType: field.baseType,
+			data:     d.data[d.offset : d.offset+int(field.size)],
+		})
+		d.offset += int(field.size)
+	}
+	if d.offset+definition.developerSize > end {
+		return d.fail(d.offset, "message goes past the end of the data")
+	}
+	d.offset += definition.developerSize
+
+	if t, ok := message.uint(fitTimestamp); ok {
+		d.timestamp = uint32(t)
+	} else if timestamp != nil {
+		d.timestamp = *timestamp
+		var order binary.AppendByteOrder = binary.LittleEndian
+		if message.bigEndian {
+			order = binary.BigEndian
+		}
+		message.setField(fitField{number: fitTimestamp, baseType: 0x86, data: order.AppendUint32(nil, *timestamp)})
+	}
+	return d.handle(message)
+}
+
+// decodeDefinition reads a definition message
+func (d *fitDecoder) decodeDefinition(header byte, end int) error {
+	start := d.offset - 1
+	if d.offset+5 > end {
+		return d.fail(start, "definition message goes past the end of the data")
+	}
+
+	definition := &fitDefinition{bigEndian: d.data[d.offset+1] == 1}
+	if definition.bigEndian {
+		definition.global = binary.BigEndian.Uint16(d.data[d.offset+2:])
+	} else {
+		definition.global = binary.LittleEndian.Uint16(d.data[d.offset+2:])
+	}
+	count := int(d.data[d.offset+4])
+	d.offset += 5
+
+	if d.offset+3*count > end {
+		return d.fail(start, "definition message goes past the end of the data")
+	}
+	for i := 0; i < count; i++ {
+		field := fitFieldDefinition{number: d.data[d.offset], size: d.data[d.offset+1], baseType: d.data[d.offset+2]}
+		if field.size == 0 {
+			return d.fail(d.offset+1, "field %d has no size", field.number)
+		}
+		definition.fields = append(definition.fields, field)
+		d.offset += 3
+	}
+
+	if header&0x20 != 0 {
+		if d.offset+1 > end {
+			return d.fail(start, "definition message goes past the end of the data")
+		}
+		developer := int(d.data[d.offset])
+		d.offset++
+		if d.offset+3*developer > end {
+			return d.fail(start, "definition message goes past the end of the data")
+		}
+		for i := 0; i < developer; i++ {
+			definition.developerSize += int(d.data[d.offset+1])
+			d.offset += 3
+		}
+	}
+
+	d.definitions[header&0x0F] = definition
+	return nil
+}
+
+// handle converts the messages which are part of the GPX model
+func (d *fitDecoder) handle(m fitMessage) error {
+	switch m.global {
+	case fitRecord, fitCoursePoint, fitLocation:
+		d.points++
+		if d.options.maxPoints > 0 && d.points > d.options.maxPoints {
+			return &LimitError{Limit: "points", Max: int64(d.options.maxPoints)}
+		}
+	}
+
+	c := &d.converter
+	switch m.global {
+	case fitFileID:
+		c.fileID(m)
+	case fitRecord:
+		c.record(m)
+	case fitLap:
+		c.laps = append(c.laps, c.span(m))
+	case fitSession:
+		session := c.span(m)
+		if value, ok := m.uint(5); ok {
+			session.sport = fitName(fitSports, value)
+		}
+		c.sessions = append(c.sessions, session)
+	case fitCourse:
+		c.course(m)
+	case fitCoursePoint:
+		c.coursePoint(m)
+	case fitLocation:
+		c.location(m)
+	}
+	return nil
+}
+
+// fitConverter builds the GPX document from the messages. Records, laps and sessions
+// are kept until the end of each file, as laps and sessions often come after their
+// records.
+type fitConverter struct {
+	gpx *GPX
+	// name and kind are those of the course
+	name, kind string
+	records    []TrackPoint
+	laps       []fitSpan
+	sessions   []fitSpan
+}
+
+// fitSpan is the time range of a lap or a session
+type fitSpan struct {
+	// start and end are zero when the message doesn't have them
+	start, end time.Time
+	// records is the number of records read before the message
+	records int
+	sport   string
+}
+
+func (c *fitConverter) fileID(m fitMessage) {
+	if name, ok := m.string(8); ok && c.gpx.Creator == "" {
+		c.gpx.Creator = name
+	} else if manufacturer, ok := m.uint(1); ok && manufacturer == 1 && c.gpx.Creator == "" {
+		c.gpx.Creator = "Garmin"
+	}
+	if created, ok := m.uint(4); ok && c.gpx.Metadata.Timestamp.IsZero() {
+		c.gpx.Metadata.Timestamp = fitTime(created)
+	}
+}
+
+func (c *fitConverter) record(m fitMessage) {
+	lat, okLat := m.int(0)
+	lon, okLon := m.int(1)
+	if !okLat || !okLon {
+		return
+	}
+
+	point := TrackPoint{Latitude: Latitude(semicircles(lat)), Longitude: Longitude(semicircles(lon))}
+	if t, ok := m.uint(fitTimestamp); ok {
+		point.Timestamp = fitTime(t)
+	}
+	if altitude, ok := m.uint(78); ok {
+		point.Elevation = fitAltitude(altitude)
+	} else if altitude, ok := m.uint(2); ok {
+		point.Elevation = fitAltitude(altitude)
+	}
+
+	extension := TrackPointExtension{}
+	if hr, ok := m.uint(3); ok {
+		extension.HeartRate = BeatsPerMinute(hr)
+	}
+	if cadence, ok := m.uint(4); ok {
+		extension.Cadence = RevolutionsPerMinute(cadence)
+	}
+	speed, ok := m.uint(73)
+	if !ok {
+		speed, ok = m.uint(6)
+	}
+	if ok {
+		metresPerSecond := MetresPerSecond(float64(speed) / 1000)
+		extension.Speed = &metresPerSecond
+	}
+	if temperature, ok := m.int(13); ok {
+		extension.Temperature = DegreesCelcius(temperature)
+	}
+	power, _ := m.uint(7)
+
+	if extension != (TrackPointExtension{}) || power != 0 {
+		point.Extensions = &TrackPointExtensions{Power: Watts(power)}
+		if extension != (TrackPointExtension{}) {
+			point.Extensions.TrackPointExtensions = &extension
+		}
+	}
+	c.records = append(c.records, point)
+}
+
+func (c *fitConverter) course(m fitMessage) {
+	if name, ok := m.string(5); ok {
+		c.name = name
+	}
+	if sport, ok := m.uint(4); ok {
+		c.kind = fitName(fitSports, sport)
+	}
+}
+
+func (c *fitConverter) coursePoint(m fitMessage) {
+	lat, okLat := m.int(2)
+	lon, okLon := m.int(3)
+	if !okLat || !okLon {
+		return
+	}
+
+	waypoint := WayPoint{Latitude: Latitude(semicircles(lat)), Longitude: Longitude(semicircles(lon))}
+	if t, ok := m.uint(1); ok {
+		waypoint.Timestamp = fitTime(t)
+	}
+	if kind, ok := m.uint(5); ok {
+		waypoint.Type = fitName(fitCoursePointTypes, kind)
+	}
+	waypoint.Name, _ = m.string(6)
+	c.gpx.Waypoints = append(c.gpx.Waypoints, waypoint)
+}
+
+func (c *fitConverter) location(m fitMessage) {
+	lat, okLat := m.int(1)
+	lon, okLon := m.int(2)
+	if !okLat || !okLon {
+		return
+	}
+
+	waypoint := WayPoint{Latitude: Latitude(semicircles(lat)), Longitude: Longitude(semicircles(lon))}
+	if t, ok := m.uint(fitTimestamp); ok {
+		waypoint.Timestamp = fitTime(t)
+	}
+	if altitude, ok := m.uint(4); ok {
+		waypoint.Elevation = fitAltitude(altitude)
+	}
+	waypoint.Name, _ = m.string(0)
+	waypoint.Description, _ = m.string(6)
+	c.gpx.Waypoints = append(c.gpx.Waypoints, waypoint)
+}
+
+// span returns the time range of a lap or a session, from its start_time and its
+// timestamp
+func (c *fitConverter) span(m fitMessage) fitSpan {
+	span := fitSpan{records: len(c.records)}
+	if start, ok := m.uint(2); ok {
+		span.start = fitTime(start).Time
+	}
+	if end, ok := m.uint(fitTimestamp); ok {
+		span.end = fitTime(end).Time
+	}
+	return span
+}
+
+// endFile adds the records of a file to the document, as a track for every session
+// with a segment for every lap
+func (c *fitConverter) endFile() {
+	type key struct{ session, lap int }
+	groups := map[key][]TrackPoint{}
+	keys := []key{}
+	for i, point := range c.records {
+		k := key{fitSpanOf(c.sessions, i, point.Timestamp.Time), fitSpanOf(c.laps, i, point.Timestamp.Time)}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], point)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].session != keys[j].session {
+			return keys[i].session < keys[j].session
+		}
+		return keys[i].lap < keys[j].lap
+	})
+
+	for i, k := range keys {
+		if i == 0 || keys[i-1].session != k.session {
+			track := Track{Name: c.name, Type: c.kind}
+			if k.session < len(c.sessions) && c.sessions[k.session].sport != "" {
+				track.Type = c.sessions[k.session].sport
+			}
+			c.gpx.Tracks = append(c.gpx.Tracks, track)
+		}
+		track := &c.gpx.Tracks[len(c.gpx.Tracks)-1]
+		track.TrackSegments = append(track.TrackSegments, TrackSegment{TrackPoint: groups[k]})
+	}
+
+	*c = fitConverter{gpx: c.gpx}
+}
+
+// fitSpanOf returns the index of the span a record belongs to, or len(spans) for
+// records after the last span. Records with a time belong to the span whose time range
+// has it, or else to the last span started before it, when spans have an end time.
+// Other records belong to the first span whose message comes after them.
+func fitSpanOf(spans []fitSpan, record int, at time.Time) int {
+	if !at.IsZero() {
+		found := -1
+		for i, span := range spans {
+			if span.end.IsZero() {
+				continue
+			}
+			started := span.start.IsZero() || !at.Before(span.start)
+			if started && !at.After(span.end) {
+				return i
+			}
+			if started {
+				found = i
+			}
+		}
+		if found >= 0 {
+			return found
+		}
+		for i, span := range spans {
+			if !span.end.IsZero() {
+				return i
+			}
+		}
+	}
+
+	for i, span := range spans {
+		if record < span.records {
+			return i
+		}
+	}
+	return len(spans)
+}
+
+// field returns the field with a number
+func (m *fitMessage) field(number byte) (fitField, bool) {
+	for _, field := range m.fields {
+		if field.number == number {
+			return field, true
+		}
+	}
+	return fitField{}, false
+}
+
+// setField replaces the field with the same number, or adds it
+func (m *fitMessage) setField(field fitField) {
+	for i := range m.fields {
+		if m.fields[i].number == field.number {
+			m.fields[i] = field
+			return
+		}
+	}
+	m.fields = append(m.fields, field)
+}
+
+// raw returns the first value of a field as bits of the size of its base type, unless
+// it is the invalid value of that type
+func (m *fitMessage) raw(number byte) (uint64, int, bool) {
+	field, ok := m.field(number)
+	if !ok {
+		return 0, 0, false
+	}
+	size := fitBaseSize(field.baseType)
+	if size == 0 || len(field.data) < size {
+		return 0, 0, false
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if m.bigEndian {
+		order = binary.BigEndian
+	}
+	var value uint64
+	switch size {
+	case 1:
+		value = uint64(field.data[0])
+	case 2:
+		value = uint64(order.Uint16(field.data))
+	case 4:
+		value = uint64(order.Uint32(field.data))
+	case 8:
+		value = order.Uint64(field.data)
+	}
+	if value == fitInvalid(field.baseType) {
+		return 0, 0, false
+	}
+	return value, size, true
+}
+
+// uint returns the first value of a field of an unsigned type
+func (m *fitMessage) uint(number byte) (uint64, bool) {
+	value, _, ok := m.raw(number)
+	return value, ok
+}
+
+// int returns the first value of a field of a signed type
+func (m *fitMessage) int(number byte) (int64, bool) {
+	value, size, ok := m.raw(number)
+	shift := 64 - 8*size
+	return int64(value<<shift) >> shift, ok
+}
+
+// string returns the value of a field of type string, up to its first null byte
+func (m *fitMessage) string(number byte) (string, bool) {
+	field, ok := m.field(number)
+	if !ok || field.baseType&0x1F != 7 {
+		return "", false
+	}
+	if i := bytes.IndexByte(field.data, 0); i >= 0 {
+		field.data = field.data[:i]
+	}
+	return string(field.data), len(field.data) > 0
+}
+
+// fitBaseSize returns the size of a value of a base type, or 0 for unknown types
+func fitBaseSize(baseType byte) int {
+	switch baseType & 0x1F {
+	case 0, 1, 2, 7, 10, 13:
+		return 1
+	case 3, 4, 11:
+		return 2
+	case 5, 6, 8, 12:
+		return 4
+	case 9, 14, 15, 16:
+		return 8
+	}
+	return 0
+}
+
+// fitInvalid returns the value which means a field of a base type has no value
+func fitInvalid(baseType byte) uint64 {
+	switch baseType & 0x1F {
+	case 1:
+		return 0x7F
+	case 3:
+		return 0x7FFF
+	case 5:
+		return 0x7FFFFFFF
+	case 14:
+		return 0x7FFFFFFFFFFFFFFF
+	case 10, 11, 12, 16:
+		return 0
+	}
+	return math.MaxUint64 >> (64 - 8*fitBaseSize(baseType))
+}
+
+// fitTime converts a FIT timestamp
+func fitTime(t uint64) DateTime {
+	return NewDateTime(fitEpoch.Add(time.Duration(t) * time.Second))
+}
+
+// fitAltitude converts a FIT altitude, which has a scale of 5 and an offset of 500 m
+func fitAltitude(altitude uint64) *float64 {
+	elevation := float64(altitude)/5 - 500
+	return &elevation
+}
+
+// semicircles converts an angle in semicircles to degrees
+func semicircles(value int64) float64 {
+	return float64(value) * 180 / (1 << 31)
+}
+
+// fitName returns the name of a value of a FIT enum, or its number for values which
+// aren't known
+func fitName(names []string, value uint64) string {
+	if value < uint64(len(names)) {
+		return names[value]
+	}
+	return fmt.Sprint(value)
+}
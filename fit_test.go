@@ -0,0 +1,121 @@
+package gpx_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_ParseFITActivity(t *testing.T) {
+	g, err := gpx.ParseFITFile("./samples/activity.fit")
+	require.Nil(t, err)
+
+	assert.Equal(t, "Garmin", g.Creator)
+	assert.Equal(t, "2019-06-15T07:30:00Z", g.Metadata.Timestamp.String())
+	require.Len(t, g.Tracks, 1)
+	assert.Equal(t, "cycling", g.Tracks[0].Type)
+	require.Len(t, g.Tracks[0].TrackSegments, 2)
+
+	points := g.Tracks[0].TrackSegments[0].TrackPoint
+	require.Len(t, points, 2)
+	assert.InDelta(t, 47.6062, float64(points[0].Latitude), 1e-7)
+	assert.InDelta(t, -122.3321, float64(points[0].Longitude), 1e-7)
+	assert.InDelta(t, 52.4, *points[0].Elevation, 1e-9)
+	assert.Equal(t, "2019-06-15T07:30:00Z", points[0].Timestamp.String())
+	require.NotNil(t, points[0].Extensions)
+	extension := points[0].Extensions.TrackPointExtensions
+	assert.Equal(t, gpx.BeatsPerMinute(120), extension.HeartRate)
+	assert.Equal(t, gpx.RevolutionsPerMinute(85), extension.Cadence)
+	assert.Equal(t, gpx.DegreesCelcius(21), extension.Temperature)
+	assert.Equal(t, gpx.MetresPerSecond(6.9), *extension.Speed)
+	assert.Equal(t, gpx.Watts(210), points[0].Extensions.Power)
+
+	// The second record has a compressed timestamp
+	assert.Equal(t, "2019-06-15T07:30:01Z", points[1].Timestamp.String())
+	assert.Equal(t, gpx.Watts(225), points[1].Extensions.Power)
+
+	last := g.Tracks[0].TrackSegments[1].TrackPoint
+	require.Len(t, last, 1)
+	assert.InDelta(t, 55, *last[0].Elevation, 1e-9)
+	assert.Nil(t, last[0].Extensions)
+}
+
+func Test_ParseFITCourse(t *testing.T) {
+	g, err := gpx.ParseFITFile("./samples/course.fit")
+	require.Nil(t, err)
+
+	assert.Equal(t, "Edge Explore", g.Creator)
+	require.Len(t, g.Tracks, 1)
+	assert.Equal(t, "Lake loop", g.Tracks[0].Name)
+	assert.Equal(t, "cycling", g.Tracks[0].Type)
+	require.Len(t, g.Tracks[0].TrackSegments, 1)
+	points := g.Tracks[0].TrackSegments[0].TrackPoint
+	require.Len(t, points, 3)
+	assert.InDelta(t, 102, *points[2].Elevation, 1e-9)
+
+	require.Len(t, g.Waypoints, 1)
+	assert.Equal(t, "Viewpoint", g.Waypoints[0].Name)
+	assert.Equal(t, "summit", g.Waypoints[0].Type)
+	assert.InDelta(t, 47.601, float64(g.Waypoints[0].Latitude), 1e-7)
+	assert.Equal(t, "2019-06-15T07:30:10Z", g.Waypoints[0].Timestamp.String())
+}
+
+func Test_ParseFITMultisport(t *testing.T) {
+	// Laps and sessions are written after every record, records have a developer field
+	// and compressed timestamps which wrap every 32 seconds
+	g, err := gpx.ParseFITFile("./samples/multisport.fit")
+	require.Nil(t, err)
+
+	require.Len(t, g.Tracks, 2)
+	assert.Equal(t, "cycling", g.Tracks[0].Type)
+	assert.Equal(t, "running", g.Tracks[1].Type)
+
+	ride := g.Tracks[0].TrackSegments
+	require.Len(t, ride, 2)
+	require.Len(t, ride[0].TrackPoint, 21)
+	require.Len(t, ride[1].TrackPoint, 24)
+	assert.Equal(t, "2019-06-15T07:30:20Z", ride[0].TrackPoint[20].Timestamp.String())
+	assert.Equal(t, "2019-06-15T07:30:44Z", ride[1].TrackPoint[23].Timestamp.String())
+	assert.Equal(t, gpx.Watts(244), ride[1].TrackPoint[23].Extensions.Power)
+
+	run := g.Tracks[1].TrackSegments
+	require.Len(t, run, 1)
+	require.Len(t, run[0].TrackPoint, 40)
+	for i, point := range run[0].TrackPoint {
+		assert.Equal(t, int64(1560583860+i), point.Timestamp.Unix())
+	}
+}
+
+func Test_ParseFITErrors(t *testing.T) {
+	data, err := os.ReadFile("./samples/activity.fit")
+	require.Nil(t, err)
+
+	corrupted := append([]byte{}, data...)
+	corrupted[40] ^= 0xFF
+	_, err = gpx.ParseFIT(bytes.NewReader(corrupted))
+	assert.True(t, errors.Is(err, gpx.ErrInvalidFIT))
+	var fitErr *gpx.FITError
+	require.True(t, errors.As(err, &fitErr))
+	assert.Equal(t, int64(len(data)-2), fitErr.Offset)
+
+	_, err = gpx.ParseFIT(bytes.NewReader(data[:len(data)-10]))
+	assert.True(t, errors.Is(err, gpx.ErrInvalidFIT))
+
+	_, err = gpx.ParseFIT(bytes.NewReader([]byte("<gpx></gpx>")))
+	assert.True(t, errors.Is(err, gpx.ErrInvalidFIT))
+
+	_, err = gpx.ParseFIT(bytes.NewReader(data), gpx.MaxPoints(2))
+	assert.True(t, errors.Is(err, gpx.ErrLimitExceeded))
+
+	_, err = gpx.ParseFIT(bytes.NewReader(data), gpx.MaxBytes(100))
+	assert.True(t, errors.Is(err, gpx.ErrLimitExceeded))
+
+	chained, err := gpx.ParseFIT(bytes.NewReader(append(append([]byte{}, data...), data...)))
+	require.Nil(t, err)
+	assert.Len(t, chained.Tracks, 2)
+}
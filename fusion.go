@@ -0,0 +1,138 @@
+package gpx
+
+import (
+	"sort"
+	"time"
+)
+
+// FusionReport tells how much of a track got sensor data from FuseSensors
+type FusionReport struct {
+	// Points counts the points of the target with a timestamp, and Matched those of
+	// them which had a point of the source close enough in time
+	Points  int
+	Matched int
+	// Coverage is Matched over Points, from 0 to 1
+	Coverage float64
+	// Unmatched lists the times of consecutive points which weren't matched, from the
+	// first to the last of them
+	Unmatched []TimeRange
+}
+
+// TimeRange is a period of time, both ends included
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// FuseSensors returns a copy of target with the heart rate, cadence, temperature and
+// power of source, like the GPS track of a phone and the sensors of a watch recorded
+// during the same session. Each point of the target takes the values of the point of
+// the source closest in time, if it is at most tolerance away. The offset is added to
+// the times of the source, to correct a clock which is late or early.
+// Values the source doesn't have are left unchanged, and so are points of the target
+// without a timestamp.
+func FuseSensors(target, source *Track, tolerance, offset time.Duration) (Track, FusionReport) {
+	type sample struct {
+		at    time.Time
+		point *TrackPoint
+	}
+	samples := []sample{}
+	for i := range source.TrackSegments {
+		for j := range source.TrackSegments[i].TrackPoint {
+			point := &source.TrackSegments[i].TrackPoint[j]
+			if point.Timestamp.IsZero() || (sensors(*point) == TrackPointExtension{} && point.power() == 0) {
+				continue
+			}
+			samples = append(samples, sample{point.Timestamp.Add(offset), point})
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].at.Before(samples[j].at)
+	})
+
+	closest := func(at time.Time) *TrackPoint {
+		i := sort.Search(len(samples), func(i int) bool {
+			return !samples[i].at.Before(at)
+		})
+		var best *TrackPoint
+		distance := tolerance
+		for _, j := range []int{i - 1, i} {
+			if j < 0 || j >= len(samples) {
+				continue
+			}
+			if d := absDuration(samples[j].at.Sub(at)); d <= distance {
+				best, distance = samples[j].point, d
+			}
+		}
+		return best
+	}
+
+	out := *target
+	out.TrackSegments = make([]TrackSegment, len(target.TrackSegments))
+	report := FusionReport{}
+	var unmatched *TimeRange
+	for i := range target.TrackSegments {
+		out.TrackSegments[i] = target.TrackSegments[i].slice(0, len(target.TrackSegments[i].TrackPoint))
+		for j := range out.TrackSegments[i].TrackPoint {
+			point := &out.TrackSegments[i].TrackPoint[j]
+			if point.Timestamp.IsZero() {
+				continue
+			}
+			report.Points++
+
+			match := closest(point.Timestamp.Time)
+			if match == nil {
+				if unmatched == nil {
+					report.Unmatched = append(report.Unmatched, TimeRange{Start: point.Timestamp.Time})
+					unmatched = &report.Unmatched[len(report.Unmatched)-1]
+				}
+				unmatched.End = point.Timestamp.Time
+				continue
+			}
+			unmatched = nil
+			report.Matched++
+			point.copySensors(match)
+		}
+	}
+
+	if report.Points > 0 {
+		report.Coverage = float64(report.Matched) / float64(report.Points)
+	}
+	return out, report
+}
+
+// copySensors sets the sensor values of the point to those of another point which has
+// them, copying its extensions so that they aren't shared with other points
+func (p *TrackPoint) copySensors(from *TrackPoint) {
+	extensions := TrackPointExtensions{}
+	if p.Extensions != nil {
+		extensions = *p.Extensions
+	}
+	extension := sensors(*p)
+	values := sensors(*from)
+
+	if values.HeartRate != 0 {
+		extension.HeartRate = values.HeartRate
+	}
+	if values.Cadence != 0 {
+		extension.Cadence = values.Cadence
+	}
+	if values.Temperature != 0 {
+		extension.Temperature = values.Temperature
+	}
+	if power := from.power(); power != 0 {
+		extensions.Power = power
+	}
+	if extension != (TrackPointExtension{}) {
+		extensions.TrackPointExtensions = &extension
+	}
+	p.Extensions = &extensions
+}
+
+// absDuration returns the absolute value of a duration
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
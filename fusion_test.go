@@ -0,0 +1,55 @@
+package gpx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_FuseSensors(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	phone := gpx.Track{Name: "Phone", TrackSegments: []gpx.TrackSegment{{}}}
+	for i := 0; i < 10; i++ {
+		phone.TrackSegments[0].TrackPoint = append(phone.TrackSegments[0].TrackPoint, trackPoint(0, float64(i)*0.0001, 0, start.Add(time.Duration(i)*time.Second)))
+	}
+	phone.TrackSegments[0].TrackPoint[0] = withSensors(phone.TrackSegments[0].TrackPoint[0], 0, 0, 18)
+	phone.TrackSegments[0].TrackPoint = append(phone.TrackSegments[0].TrackPoint, gpx.TrackPoint{})
+
+	// The clock of the watch is 30 seconds late, and it missed a few seconds
+	late := start.Add(-30 * time.Second)
+	watch := gpx.Track{TrackSegments: []gpx.TrackSegment{{TrackPoint: []gpx.TrackPoint{
+		withSensors(trackPoint(0, 0, 0, late.Add(-100*time.Millisecond)), 100, 80, 0),
+		withSensors(trackPoint(0, 0, 0, late.Add(1200*time.Millisecond)), 101, 81, 0),
+		withSensors(trackPoint(0, 0, 0, late.Add(2400*time.Millisecond)), 102, 82, 0),
+		trackPoint(0, 0, 0, late.Add(5*time.Second)),
+		withSensors(trackPoint(0, 0, 0, late.Add(8*time.Second)), 108, 88, 0),
+		withSensors(trackPoint(0, 0, 0, late.Add(9*time.Second)), 109, 89, 0),
+	}}}}
+	watch.TrackSegments[0].TrackPoint[5].Extensions.Power = 250
+
+	fused, report := gpx.FuseSensors(&phone, &watch, 500*time.Millisecond, 30*time.Second)
+	assert.Equal(t, "Phone", fused.Name)
+	points := fused.TrackSegments[0].TrackPoint
+	require.Len(t, points, 11)
+
+	assert.Equal(t, gpx.BeatsPerMinute(100), points[0].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.DegreesCelcius(18), points[0].Extensions.TrackPointExtensions.Temperature)
+	assert.Equal(t, gpx.BeatsPerMinute(101), points[1].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.RevolutionsPerMinute(82), points[2].Extensions.TrackPointExtensions.Cadence)
+	assert.Nil(t, points[3].Extensions)
+	assert.Equal(t, gpx.Watts(250), points[9].Extensions.Power)
+	assert.Nil(t, points[10].Extensions)
+
+	assert.Equal(t, 10, report.Points)
+	assert.Equal(t, 5, report.Matched)
+	assert.InDelta(t, 0.5, report.Coverage, 1e-9)
+	assert.Equal(t, []gpx.TimeRange{
+		{Start: start.Add(3 * time.Second), End: start.Add(7 * time.Second)},
+	}, report.Unmatched)
+
+	assert.Equal(t, gpx.BeatsPerMinute(0), phone.TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.HeartRate)
+	assert.Nil(t, phone.TrackSegments[0].TrackPoint[1].Extensions)
+}
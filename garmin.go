@@ -1,16 +1,19 @@
 package gpx
 
-import "encoding/xml"
+import xml "github.com/Zauberstuhl/go-xml"
 
 // This files defines Garmin extensions to be used with the GPX 1.1 schema
 // https://www8.garmin.com/xmlschemas/GpxExtensions/v3/GpxExtensionsv3.xsd
 // https://www8.garmin.com/xmlschemas/WaypointExtensionv1.xsd
 // https://www8.garmin.com/xmlschemas/TrackPointExtensionv1.xsd
+// https://www8.garmin.com/xmlschemas/TrackPointExtensionv2.xsd
+// https://www8.garmin.com/xmlschemas/PowerExtensionv1.xsd
 
 // WayPointExtensions extend GPX by adding your own elements from another schema
 type WayPointExtensions struct {
 	XMLName            xml.Name           `xml:"extensions"`
 	WayPointExtensions *WayPointExtension `xml:"gpxx:WaypointExtension,omitempty"`
+	Unknown            []RawElement       `xml:",any"`
 }
 
 // WayPointExtension add data fields available in Garmin GDB waypoints that cannot be represented in waypoints in GPX 1.1 instances
@@ -30,52 +33,83 @@ type WayPointExtension struct {
 // RouteExtensions extend GPX by adding your own elements from another schema
 type RouteExtensions struct {
 	XMLName         xml.Name        `xml:"extensions"`
-	RouteExtensions *RouteExtension `xml:"gpxtpx:RouteExtension,omitempty"`
+	RouteExtensions *RouteExtension `xml:"gpxx:RouteExtension,omitempty"`
+	Unknown         []RawElement    `xml:",any"`
 }
 
 // RouteExtension tracks temperature, heart rate and cadence specific to garmin devices
 type RouteExtension struct {
-	XMLName      xml.Name            `xml:"gpxtpx:RouteExtension"`
-	IsAutoNamed  bool                `xml:"gpxtpx:IsAutoNamed,omitempty"`
-	DisplayColor DisplayColor        `xml:"gpxtpx:DisplayColor,omitempty"`
-	Extensions   *GarminExtensionsV1 `xml:"gpxtpx:Extensions,omitempty"`
+	XMLName      xml.Name          `xml:"gpxx:RouteExtension"`
+	IsAutoNamed  bool              `xml:"gpxx:IsAutoNamed,omitempty"`
+	DisplayColor DisplayColor      `xml:"gpxx:DisplayColor,omitempty"`
+	Extensions   *GarminExtensions `xml:"gpxx:Extensions,omitempty"`
 }
 
 // RoutePointExtensions extend GPX by adding your own elements from another schema
 type RoutePointExtensions struct {
 	XMLName              xml.Name             `xml:"extensions"`
-	RoutePointExtensions *RoutePointExtension `xml:"gpxtpx:RoutePointExtension,omitempty"`
+	RoutePointExtensions *RoutePointExtension `xml:"gpxx:RoutePointExtension,omitempty"`
+	Unknown              []RawElement         `xml:",any"`
 }
 
 // RoutePointExtension tracks temperature, heart rate and cadence specific to garmin devices
 type RoutePointExtension struct {
-	XMLName        xml.Name            `xml:"gpxtpx:RoutePointExtension"`
-	Subclass       SubClass            `xml:"gpxtpx:Subclass,omitempty"`
-	AutoRoutePoint AutoRoutePoint      `xml:"gpxtpx:rpt,omitempty"`
-	Extensions     *GarminExtensionsV1 `xml:"gpxtpx:Extensions,omitempty"`
+	XMLName        xml.Name          `xml:"gpxx:RoutePointExtension"`
+	Subclass       SubClass          `xml:"gpxx:Subclass,omitempty"`
+	AutoRoutePoint []AutoRoutePoint  `xml:"gpxx:rpt,omitempty"`
+	Extensions     *GarminExtensions `xml:"gpxx:Extensions,omitempty"`
 }
 
 // TrackExtensions extend GPX by adding your own elements from another schema
 type TrackExtensions struct {
 	XMLName         xml.Name        `xml:"extensions"`
-	TrackExtensions *TrackExtension `xml:"gpxtpx:TrackExtension,omitempty"`
+	TrackExtensions *TrackExtension `xml:"gpxx:TrackExtension,omitempty"`
+	Unknown         []RawElement    `xml:",any"`
 }
 
 // TrackExtension tracks temperature, heart rate and cadence specific to garmin devices
 type TrackExtension struct {
-	XMLName      xml.Name            `xml:"gpxtpx:TrackExtension"`
-	DisplayColor DisplayColor        `xml:"gpxtpx:DisplayColor,omitempty"`
-	Extensions   *GarminExtensionsV1 `xml:"gpxtpx:Extensions,omitempty"`
+	XMLName      xml.Name          `xml:"gpxx:TrackExtension"`
+	DisplayColor DisplayColor      `xml:"gpxx:DisplayColor,omitempty"`
+	Extensions   *GarminExtensions `xml:"gpxx:Extensions,omitempty"`
 }
 
 // TrackPointExtensions extend GPX by adding your own elements from another schema
 type TrackPointExtensions struct {
 	XMLName              xml.Name             `xml:"extensions"`
 	TrackPointExtensions *TrackPointExtension `xml:"gpxtpx:TrackPointExtension,omitempty"`
+	Power                Watts                `xml:"pwr:PowerInWatts,omitempty"`
+	Unknown              []RawElement         `xml:",any"`
+}
+
+// UnmarshalXML reads the extensions of a track point. Power is also read from the
+// `power` element without namespace written by Strava, but is only ever written as
+// PowerInWatts from https://www8.garmin.com/xmlschemas/PowerExtensionv1.xsd
+func (e *TrackPointExtensions) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	// The embedded type has to be exported for the decoder to fill it
+	type Plain TrackPointExtensions
+	extensions := struct {
+		Plain
+		StravaPower Watts `xml:"power"`
+	}{}
+
+	if err := d.DecodeElement(&extensions, &start); err != nil {
+		return err
+	}
+
+	*e = TrackPointExtensions(extensions.Plain)
+	if e.Power == 0 {
+		e.Power = extensions.StravaPower
+	}
+	return nil
 }
 
 // TrackPointExtension tracks temperature, heart rate and cadence specific to garmin devices
 // From https://www8.garmin.com/xmlschemas/TrackPointExtensionv1.xsd
+// Speed, Course and Bearing only exist in https://www8.garmin.com/xmlschemas/TrackPointExtensionv2.xsd,
+// which is written instead of v1 when any track point in the file has one of them.
+// Speed, Course and Bearing are pointers so that stops and due north, which are zero,
+// can be told from no value.
 type TrackPointExtension struct {
 	XMLName      xml.Name             `xml:"gpxtpx:TrackPointExtension"`
 	Temperature  DegreesCelcius       `xml:"gpxtpx:atemp,omitempty"`
@@ -83,9 +117,25 @@ type TrackPointExtension struct {
 	Depth        Metres               `xml:"gpxtpx:depth,omitempty"`
 	HeartRate    BeatsPerMinute       `xml:"gpxtpx:hr,omitempty"`
 	Cadence      RevolutionsPerMinute `xml:"gpxtpx:cad,omitempty"`
+	Speed        *MetresPerSecond     `xml:"gpxtpx:speed,omitempty"`
+	Course       *Degrees             `xml:"gpxtpx:course,omitempty"`
+	Bearing      *Degrees             `xml:"gpxtpx:bearing,omitempty"`
 	Extensions   *GarminExtensionsV1  `xml:"gpxtpx:Extensions,omitempty"`
 }
 
+// isV2 tells if the extension has data which can only be written with TrackPointExtension/v2
+func (e *TrackPointExtension) isV2() bool {
+	return e.Speed != nil || e.Course != nil || e.Bearing != nil
+}
+
+// sensors returns the TrackPointExtension of a point, or an empty one
+func sensors(p TrackPoint) TrackPointExtension {
+	if p.Extensions == nil || p.Extensions.TrackPointExtensions == nil {
+		return TrackPointExtension{}
+	}
+	return *p.Extensions.TrackPointExtensions
+}
+
 // TrackPointExtension tracks temperature, heart rate and cadence specific to garmin devices
 // From https://www8.garmin.com/xmlschemas/GpxExtensions/v3/GpxExtensionsv3.xsd
 // type TrackPointExtension struct {
@@ -97,12 +147,14 @@ type TrackPointExtension struct {
 
 // GarminExtensions handles extensions in garmin extensions
 type GarminExtensions struct {
-	XMLName xml.Name `xml:"gpxx:Extensions"`
+	XMLName xml.Name     `xml:"gpxx:Extensions"`
+	Unknown []RawElement `xml:",any"`
 }
 
 // GarminExtensionsV1 handles extensions in garmin extensions
 type GarminExtensionsV1 struct {
-	XMLName xml.Name `xml:"gpxtpx:Extensions"`
+	XMLName xml.Name     `xml:"gpxtpx:Extensions"`
+	Unknown []RawElement `xml:",any"`
 }
 
 // Categories contains a list of categories that a waypoint has been assigned
@@ -124,9 +176,9 @@ type Address struct {
 
 // PhoneNumber saves the phone number and type
 type PhoneNumber struct {
-	XMLName  xml.Name `xml:"gpxtpx:PhoneNumber"`
-	Category string   `xml:"gpxtpx:Category,attr,omitempty"`
-	Number   string   `xml:"gpxtpx:Number,omitempty"`
+	XMLName  xml.Name `xml:"gpxx:PhoneNumber"`
+	Category string   `xml:"Category,attr,omitempty"`
+	Number   string   `xml:",chardata"`
 }
 
 // DisplayMode contains a string that specifies how a waypoint should be displayed on a map.
@@ -183,10 +235,10 @@ const (
 
 // AutoRoutePoint (not sure what this does)
 type AutoRoutePoint struct {
-	XMLName   xml.Name  `xml:"gpxtpx:rpt"`
-	Latitude  Latitude  `xml:"gpxtpx:lat,attr,omitempty"`
-	Longitude Longitude `xml:"gpxtpx:lon,attr,omitempty"`
-	SubClass  SubClass  `xml:"gpxtpx:Subclass,omitempty"`
+	XMLName   xml.Name  `xml:"gpxx:rpt"`
+	Latitude  Latitude  `xml:"lat,attr"`
+	Longitude Longitude `xml:"lon,attr"`
+	SubClass  SubClass  `xml:"gpxx:Subclass,omitempty"`
 }
 
 // SubClass (not sure what this does)
@@ -195,6 +247,9 @@ type SubClass string
 // Metres is used to measure length
 type Metres float64
 
+// MetresPerSecond is used to measure speed
+type MetresPerSecond float64
+
 // DegreesCelcius is used to measure degree celcius
 type DegreesCelcius float64
 
@@ -203,3 +258,6 @@ type BeatsPerMinute int
 
 // RevolutionsPerMinute is used to measure cadence
 type RevolutionsPerMinute int
+
+// Watts is used to measure power
+type Watts int
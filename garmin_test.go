@@ -0,0 +1,102 @@
+package gpx_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_TrackPointExtensionV2Parser(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/trackpointextension-v2.gpx")
+	require.Nil(t, err)
+
+	ext := g.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions
+	assert.Equal(t, gpx.DegreesCelcius(14), ext.Temperature)
+	assert.Equal(t, gpx.BeatsPerMinute(112), ext.HeartRate)
+	assert.Equal(t, gpx.RevolutionsPerMinute(78), ext.Cadence)
+	assert.Equal(t, gpx.MetresPerSecond(6.42), *ext.Speed)
+	assert.Equal(t, gpx.Degrees(87.5), *ext.Course)
+	assert.Equal(t, gpx.Degrees(88), *ext.Bearing)
+
+	ext = g.Tracks[0].TrackSegments[0].TrackPoint[1].Extensions.TrackPointExtensions
+	assert.Equal(t, gpx.MetresPerSecond(6.51), *ext.Speed)
+	assert.Nil(t, ext.Bearing)
+}
+
+func Test_TrackPointExtensionVersionOnWrite(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/trackpointextension-v2.gpx")
+	require.Nil(t, err)
+
+	buf := bytes.Buffer{}
+	encoder := gpx.NewEncoder(&buf)
+	require.Nil(t, encoder.Encode(g))
+	assert.Contains(t, buf.String(), `xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v2"`)
+	assert.Contains(t, buf.String(), `http://www.garmin.com/xmlschemas/TrackPointExtensionv2.xsd`)
+	assert.NotContains(t, buf.String(), `TrackPointExtension/v1`)
+	assert.Contains(t, buf.String(), `<gpxtpx:speed>6.42</gpxtpx:speed>`)
+	assert.Equal(t, gpx.TrackPointExtensionV2Namespace, namespacesOf(t, buf.Bytes())["speed"])
+
+	for i := range g.Tracks[0].TrackSegments[0].TrackPoint {
+		ext := g.Tracks[0].TrackSegments[0].TrackPoint[i].Extensions.TrackPointExtensions
+		ext.Speed, ext.Course, ext.Bearing = nil, nil, nil
+	}
+
+	buf.Reset()
+	require.Nil(t, encoder.Encode(g))
+	assert.Contains(t, buf.String(), `xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1"`)
+	assert.NotContains(t, buf.String(), `TrackPointExtension/v2`)
+	assert.Equal(t, gpx.TrackPointExtensionNamespace, namespacesOf(t, buf.Bytes())["hr"])
+
+	// Due north is a course, which is written with v2
+	north := gpx.Degrees(0)
+	g.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.Course = &north
+	buf.Reset()
+	require.Nil(t, encoder.Encode(g))
+	assert.Contains(t, buf.String(), `xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v2"`)
+	assert.Contains(t, buf.String(), `<gpxtpx:course>0</gpxtpx:course>`)
+
+	parsed, err := gpx.ParseReader(&buf)
+	require.Nil(t, err)
+	course := parsed.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.Course
+	require.NotNil(t, course)
+	assert.Equal(t, gpx.Degrees(0), *course)
+
+	// So is a stop
+	stopped := gpx.MetresPerSecond(0)
+	ext := g.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions
+	ext.Course, ext.Speed = nil, &stopped
+	buf.Reset()
+	require.Nil(t, encoder.Encode(g))
+	assert.Contains(t, buf.String(), `xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v2"`)
+	assert.Contains(t, buf.String(), `<gpxtpx:speed>0</gpxtpx:speed>`)
+
+	parsed, err = gpx.ParseReader(&buf)
+	require.Nil(t, err)
+	speed := parsed.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.Speed
+	require.NotNil(t, speed)
+	assert.Equal(t, gpx.MetresPerSecond(0), *speed)
+}
+
+func Test_PowerParser(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/power.gpx")
+	require.Nil(t, err)
+
+	points := g.Tracks[0].TrackSegments[0].TrackPoint
+	assert.Equal(t, gpx.Watts(245), points[0].Extensions.Power)
+	assert.Equal(t, gpx.BeatsPerMinute(131), points[0].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.Watts(260), points[1].Extensions.Power)
+	assert.Equal(t, gpx.RevolutionsPerMinute(90), points[1].Extensions.TrackPointExtensions.Cadence)
+	assert.Nil(t, points[2].Extensions)
+
+	buf := bytes.Buffer{}
+	encoder := gpx.NewEncoder(&buf)
+	require.Nil(t, encoder.Encode(g))
+	assert.Contains(t, buf.String(), `xmlns:pwr="http://www.garmin.com/xmlschemas/PowerExtension/v1"`)
+	assert.Contains(t, buf.String(), `http://www.garmin.com/xmlschemas/PowerExtensionv1.xsd`)
+	assert.Contains(t, buf.String(), `<pwr:PowerInWatts>260</pwr:PowerInWatts>`)
+	assert.NotContains(t, buf.String(), `<power>`)
+	assert.Equal(t, gpx.PowerExtensionNamespace, namespacesOf(t, buf.Bytes())["PowerInWatts"])
+}
@@ -0,0 +1,270 @@
+package gpx
+
+import (
+	"math"
+)
+
+// earthRadius is the mean radius of the earth in metres, and the others are the
+// WGS84 ellipsoid parameters
+const (
+	earthRadius = 6371008.8
+
+	wgs84SemiMajorAxis = 6378137.0
+	wgs84Flattening    = 1 / 298.257223563
+	wgs84SemiMinorAxis = wgs84SemiMajorAxis * (1 - wgs84Flattening)
+)
+
+// Position is implemented by every type which has a latitude and a longitude,
+// so that WayPoint, RoutePoint, TrackPoint and Point can be used interchangeably
+type Position interface {
+	LatLon() (Latitude, Longitude)
+}
+
+// LatLon returns the position of the waypoint
+func (p WayPoint) LatLon() (Latitude, Longitude) { return p.Latitude, p.Longitude }
+
+// LatLon returns the position of the route point
+func (p RoutePoint) LatLon() (Latitude, Longitude) { return p.Latitude, p.Longitude }
+
+// LatLon returns the position of the track point
+func (p TrackPoint) LatLon() (Latitude, Longitude) { return p.Latitude, p.Longitude }
+
+// LatLon returns the position of the point
+func (p Point) LatLon() (Latitude, Longitude) { return p.Latitude, p.Longitude }
+
+// Radians converts the latitude to radians
+func (l Latitude) Radians() float64 { return float64(l) * math.Pi / 180 }
+
+// Radians converts the longitude to radians
+func (l Longitude) Radians() float64 { return float64(l) * math.Pi / 180 }
+
+// Radians converts the degrees to radians
+func (d Degrees) Radians() float64 { return float64(d) * math.Pi / 180 }
+
+// Distance returns the great-circle distance between two positions using the
+// haversine formula. It is fast and within 0.5% of the ellipsoidal distance.
+func Distance(from, to Position) Metres {
+	lat1, lon1 := from.LatLon()
+	lat2, lon2 := to.LatLon()
+	return Metres(haversine(float64(lat1), float64(lon1), float64(lat2), float64(lon2)))
+}
+
+// EllipsoidalDistance returns the distance between two positions on the WGS84
+// ellipsoid using Vincenty's inverse formula, which is accurate to within a millimetre.
+// Nearly antipodal positions, for which the formula doesn't converge, are solved by
+// bisection instead, see antipodalDistance.
+func EllipsoidalDistance(from, to Position) Metres {
+	lat1, lon1 := from.LatLon()
+	lat2, lon2 := to.LatLon()
+
+	const f = wgs84Flattening
+
+	L := lon2.Radians() - lon1.Radians()
+	U1 := math.Atan((1 - f) * math.Tan(lat1.Radians()))
+	U2 := math.Atan((1 - f) * math.Tan(lat2.Radians()))
+	sinU1, cosU1 := math.Sincos(U1)
+	sinU2, cosU2 := math.Sincos(U2)
+
+	lambda := L
+	var sinSigma, cosSigma, sigma, cosSqAlpha, cos2SigmaM float64
+	for i := 0; ; i++ {
+		if i == 200 {
+			return antipodalDistance(U1, U2, L)
+		}
+
+		sinLambda, cosLambda := math.Sincos(lambda)
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		C := f / 16 * cosSqAlpha * (4 + f*(4-3*cosSqAlpha))
+
+		previous := lambda
+		lambda = L + (1-C)*f*sinAlpha*(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-previous) < 1e-12 {
+			break
+		}
+	}
+
+	return geodesicLength(cosSqAlpha, sigma, cos2SigmaM)
+}
+
+// antipodalDistance returns the length of the geodesic between two reduced latitudes
+// L radians of longitude apart, for positions where Vincenty's iteration on the
+// longitude doesn't converge. It looks instead for the azimuth at the first position
+// for which the geodesic reaches the longitude of the second one, by bisection since
+// that longitude only grows with the azimuth, which converges for every pair of
+// positions. Longitudes and lengths use Vincenty's series, so the distance is as
+// accurate as Vincenty's, within a millimetre.
+func antipodalDistance(U1, U2, L float64) Metres {
+	const a, f = wgs84SemiMajorAxis, wgs84Flattening
+
+	// The distance doesn't change when the positions are swapped or mirrored, so the
+	// first one is taken as the furthest from the equator and in the south, and the
+	// second one as east of it
+	if math.Abs(U2) > math.Abs(U1) {
+		U1, U2 = U2, U1
+	}
+	if U1 > 0 {
+		U1, U2 = -U1, -U2
+	}
+	L = math.Abs(math.Remainder(L, 2*math.Pi))
+	sinU1, cosU1 := math.Sincos(U1)
+	sinU2, cosU2 := math.Sincos(U2)
+
+	low, high := 0.0, math.Pi
+	if sinU1 == 0 {
+		// Between points on the equator, the geodesic follows it unless they are
+		// nearly antipodal, and otherwise leaves it southwards
+		if L <= (1-f)*math.Pi {
+			return Metres(a * L)
+		}
+		low = math.Pi / 2
+	}
+
+	// geodesic returns the longitude reached, the arc length on the auxiliary sphere
+	// and the values of the series for the geodesic with azimuth alpha1
+	geodesic := func(alpha1 float64) (lambda, cosSqAlpha, sigma, cos2SigmaM float64) {
+		sinAlpha1, cosAlpha1 := math.Sincos(alpha1)
+		sinAlpha := sinAlpha1 * cosU1
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cosAlpha2 := math.Sqrt(math.Max(0, cosAlpha1*cosAlpha1*cosU1*cosU1+cosU2*cosU2-cosU1*cosU1)) / cosU2
+
+		sigma1 := math.Atan2(sinU1, cosAlpha1*cosU1)
+		sigma2 := math.Atan2(sinU2, cosAlpha2*cosU2)
+		sigma = math.Atan2(math.Max(0, math.Sin(sigma2-sigma1)), math.Cos(sigma2-sigma1))
+		omega1 := math.Atan2(sinAlpha*sinU1, cosAlpha1*cosU1)
+		omega2 := math.Atan2(sinAlpha*sinU2, cosAlpha2*cosU2)
+		omega := math.Atan2(math.Max(0, math.Sin(omega2-omega1)), math.Cos(omega2-omega1))
+
+		sinSigma, cosSigma := math.Sincos(sigma)
+		cos2SigmaM = math.Cos(2*sigma1 + sigma)
+		C := f / 16 * cosSqAlpha * (4 + f*(4-3*cosSqAlpha))
+		lambda = omega - (1-C)*f*sinAlpha*(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		return lambda, cosSqAlpha, sigma, cos2SigmaM
+	}
+
+	for range 64 {
+		middle := (low + high) / 2
+		if lambda, _, _, _ := geodesic(middle); lambda < L {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	_, cosSqAlpha, sigma, cos2SigmaM := geodesic((low + high) / 2)
+	return geodesicLength(cosSqAlpha, sigma, cos2SigmaM)
+}
+
+// geodesicLength returns the length of a geodesic from its arc length on the auxiliary
+// sphere, with Vincenty's series
+func geodesicLength(cosSqAlpha, sigma, cos2SigmaM float64) Metres {
+	const a, b = wgs84SemiMajorAxis, wgs84SemiMinorAxis
+
+	sinSigma, cosSigma := math.Sincos(sigma)
+	uSq := cosSqAlpha * (a*a - b*b) / (b * b)
+	A := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	B := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	deltaSigma := B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+
+	return Metres(b * A * (sigma - deltaSigma))
+}
+
+// InitialBearing returns the bearing to follow from one position to reach the other
+// along a great circle
+func InitialBearing(from, to Position) Degrees {
+	lat1, lon1 := from.LatLon()
+	lat2, lon2 := to.LatLon()
+
+	deltaLambda := lon2.Radians() - lon1.Radians()
+	y := math.Sin(deltaLambda) * math.Cos(lat2.Radians())
+	x := math.Cos(lat1.Radians())*math.Sin(lat2.Radians()) -
+		math.Sin(lat1.Radians())*math.Cos(lat2.Radians())*math.Cos(deltaLambda)
+	return normaliseDegrees(math.Atan2(y, x) * 180 / math.Pi)
+}
+
+// FinalBearing returns the bearing on arrival at the second position when following
+// a great circle from the first one
+func FinalBearing(from, to Position) Degrees {
+	return normaliseDegrees(float64(InitialBearing(to, from)) + 180)
+}
+
+// Destination returns the point reached by travelling the distance along a great circle
+// starting from a position with the given initial bearing
+func Destination(from Position, bearing Degrees, distance Metres) Point {
+	lat, lon := from.LatLon()
+
+	delta := float64(distance) / earthRadius
+	theta := bearing.Radians()
+	phi1 := lat.Radians()
+	lambda1 := lon.Radians()
+
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(phi1), math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2))
+
+	return Point{
+		Latitude:  Latitude(phi2 * 180 / math.Pi),
+		Longitude: Longitude(normaliseLongitude(lambda2 * 180 / math.Pi)),
+	}
+}
+
+// Intermediate returns the point at a fraction of the way from one position to the
+// other along a great circle, 0 being the first position and 1 the second
+func Intermediate(from, to Position, fraction float64) Point {
+	lat1, lon1 := from.LatLon()
+	lat2, lon2 := to.LatLon()
+
+	delta := haversine(float64(lat1), float64(lon1), float64(lat2), float64(lon2)) / earthRadius
+	if delta == 0 {
+		return Point{Latitude: lat1, Longitude: lon1}
+	}
+
+	phi1, lambda1 := lat1.Radians(), lon1.Radians()
+	phi2, lambda2 := lat2.Radians(), lon2.Radians()
+
+	a := math.Sin((1-fraction)*delta) / math.Sin(delta)
+	b := math.Sin(fraction*delta) / math.Sin(delta)
+	x := a*math.Cos(phi1)*math.Cos(lambda1) + b*math.Cos(phi2)*math.Cos(lambda2)
+	y := a*math.Cos(phi1)*math.Sin(lambda1) + b*math.Cos(phi2)*math.Sin(lambda2)
+	z := a*math.Sin(phi1) + b*math.Sin(phi2)
+
+	return Point{
+		Latitude:  Latitude(math.Atan2(z, math.Hypot(x, y)) * 180 / math.Pi),
+		Longitude: Longitude(normaliseLongitude(math.Atan2(y, x) * 180 / math.Pi)),
+	}
+}
+
+// haversine returns the great-circle distance in metres between two points
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	deltaPhi := (lat2 - lat1) * math.Pi / 180
+	deltaLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// normaliseDegrees brings an angle into [0, 360)
+func normaliseDegrees(degrees float64) Degrees {
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	return Degrees(degrees)
+}
+
+// normaliseLongitude brings a longitude into [-180, 180)
+func normaliseLongitude(longitude float64) float64 {
+	return math.Mod(math.Mod(longitude+180, 360)+360, 360) - 180
+}
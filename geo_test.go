@@ -0,0 +1,50 @@
+package gpx_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_Distance(t *testing.T) {
+	flinders := gpx.Point{Latitude: -37.95103341666667, Longitude: 144.42486788888888}
+	buninyong := gpx.WayPoint{Latitude: -37.65282113888889, Longitude: 143.92649552777777}
+
+	assert.InDelta(t, 54972.271, float64(gpx.EllipsoidalDistance(flinders, buninyong)), 0.001)
+
+	assert.InDelta(t, 54972.271, float64(gpx.Distance(flinders, buninyong)), 250)
+	assert.Equal(t, gpx.Metres(0), gpx.Distance(flinders, flinders))
+
+	assert.InDelta(t, 111319.491, float64(gpx.EllipsoidalDistance(gpx.TrackPoint{}, gpx.RoutePoint{Longitude: 1})), 0.001)
+
+	// Nearly antipodal positions, where Vincenty's formula doesn't converge
+	assert.InDelta(t, 19936288.579, float64(gpx.EllipsoidalDistance(gpx.Point{}, gpx.Point{Latitude: 0.5, Longitude: 179.5})), 0.001)
+	assert.InDelta(t, 19944127.421, float64(gpx.EllipsoidalDistance(gpx.Point{Latitude: -0.5, Longitude: 0.3}, gpx.Point{Longitude: -180})), 0.001)
+	assert.InDelta(t, 20003931.459, float64(gpx.EllipsoidalDistance(gpx.Point{}, gpx.Point{Longitude: 180})), 0.001)
+}
+
+func Test_Bearing(t *testing.T) {
+	origin := gpx.Point{}
+
+	assert.InDelta(t, 0, float64(gpx.InitialBearing(origin, gpx.Point{Latitude: 1})), 1e-9)
+	assert.InDelta(t, 90, float64(gpx.InitialBearing(origin, gpx.Point{Longitude: 1})), 1e-9)
+	assert.InDelta(t, 180, float64(gpx.InitialBearing(origin, gpx.Point{Latitude: -1})), 1e-9)
+	assert.InDelta(t, 270, float64(gpx.InitialBearing(origin, gpx.Point{Longitude: -1})), 1e-9)
+
+	london := gpx.Point{Latitude: 51.5074, Longitude: -0.1278}
+	newYork := gpx.Point{Latitude: 40.7128, Longitude: -74.0060}
+	assert.InDelta(t, 288.3, float64(gpx.InitialBearing(london, newYork)), 0.1)
+	assert.InDelta(t, 231.2, float64(gpx.FinalBearing(london, newYork)), 0.1)
+}
+
+func Test_Destination(t *testing.T) {
+	start := gpx.TrackPoint{Latitude: 51.5074, Longitude: -0.1278}
+
+	end := gpx.Destination(start, 45, 10000)
+	assert.InDelta(t, 10000, float64(gpx.Distance(start, end)), 1e-6)
+	assert.InDelta(t, 45, float64(gpx.InitialBearing(start, end)), 1e-6)
+
+	wrapped := gpx.Destination(gpx.Point{Longitude: 179.9}, 90, 100000)
+	assert.True(t, wrapped.Longitude < -179)
+}
@@ -0,0 +1,647 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// GeoJSON geometry types which are read and written
+const (
+	geoJSONPoint           = "Point"
+	geoJSONMultiPoint      = "MultiPoint"
+	geoJSONLineString      = "LineString"
+	geoJSONMultiLineString = "MultiLineString"
+)
+
+// CoordinateProperties makes WriteGeoJSON add the time, heart rate, cadence,
+// temperature and power of every track point to the properties of tracks, in arrays
+// under coordinateProperties with an array for each segment, like the coordinates
+func CoordinateProperties() WriteOption {
+	return func(o *writeOptions) {
+		o.coordinates = true
+	}
+}
+
+// WriteGeoJSON writes the document as a GeoJSON FeatureCollection: a Point for each
+// waypoint, a LineString for each route and a MultiLineString for each track with a
+// line for each segment. Coordinates have the elevation when their point has one. Names, descriptions, types, times and the values of Garmin extensions are
+// written as properties with the names of their GPX elements.
+// JSON is compact unless the Indent option is given.
+func WriteGeoJSON(w io.Writer, g *GPX, opts ...WriteOption) error {
+	o := newWriteOptions(opts)
+	collection := geoJSONCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+
+	for i := range g.Waypoints {
+		collection.Features = append(collection.Features, newWayPointFeature(&g.Waypoints[i]))
+	}
+	for i := range g.Routes {
+		collection.Features = append(collection.Features, newRouteFeature(&g.Routes[i]))
+	}
+	for i := range g.Tracks {
+		collection.Features = append(collection.Features, newTrackFeature(&g.Tracks[i], o.coordinates))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent(o.prefix, o.indent)
+	return encoder.Encode(collection)
+}
+
+// ParseGeoJSON reads a GeoJSON FeatureCollection, Feature or geometry written by
+// WriteGeoJSON or by other tools into a GPX document. Points become waypoints, and so
+// does every point of a MultiPoint, and MultiLineStrings become tracks. LineStrings
+// become routes, or tracks when they have coordinate properties, as tools like
+// togeojson write tracks with a single segment. The properties and coordinate
+// properties WriteGeoJSON writes are read when they have the expected JSON type, and
+// other properties and geometries are skipped. Coordinate properties can also be flat
+// arrays for a LineString.
+// Errors are a SyntaxError for invalid JSON, errors matching ErrInvalidCoordinate for
+// positions without a longitude and a latitude or out of range, an IOError, and with
+// the MaxBytes and MaxPoints options a LimitError.
+func ParseGeoJSON(r io.Reader, opts ...ReadOption) (*GPX, error) {
+	g := GPX{Version: "1.1"}
+	o := newReadOptions(opts)
+
+	if o.maxBytes > 0 {
+		r = io.LimitReader(r, o.maxBytes+1)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return &g, &IOError{Err: err}
+	}
+	if o.maxBytes > 0 && int64(len(data)) > o.maxBytes {
+		return &g, &LimitError{Limit: "bytes", Max: o.maxBytes}
+	}
+
+	root := geoJSONMembers{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return &g, newGeoJSONSyntaxError(data, err)
+	}
+
+	features := []geoJSONMembers{}
+	switch root.kind() {
+	case "FeatureCollection":
+		collection := []json.RawMessage{}
+		geoJSONMember(root, "features", &collection)
+		for _, raw := range collection {
+			feature := geoJSONMembers{}
+			if json.Unmarshal(raw, &feature) == nil {
+				features = append(features, feature)
+			}
+		}
+	case "Feature":
+		features = append(features, root)
+	default:
+		features = append(features, geoJSONMembers{"geometry": data})
+	}
+
+	for i := range features {
+		if err := g.addFeature(features[i]); err != nil {
+			return &g, fmt.Errorf("feature %d: %w", i, err)
+		}
+	}
+	if o.maxPoints > 0 && g.points() > o.maxPoints {
+		return &g, &LimitError{Limit: "points", Max: int64(o.maxPoints)}
+	}
+	return &g, nil
+}
+
+// geoJSONMembers are the members of a GeoJSON object as they are read. Members are
+// decoded when they are used, so that those of another type than expected, which other
+// tools may write, can be ignored.
+type geoJSONMembers map[string]json.RawMessage
+
+// kind returns the type of the object
+func (m geoJSONMembers) kind() string {
+	kind := ""
+	geoJSONMember(m, "type", &kind)
+	return kind
+}
+
+// geoJSONMember sets value to a member of an object if it has one of the type of value
+func geoJSONMember[T any](m geoJSONMembers, key string, value *T) bool {
+	raw, ok := m[key]
+	if !ok {
+		return false
+	}
+	var v T
+	if json.Unmarshal(raw, &v) != nil {
+		return false
+	}
+	*value = v
+	return true
+}
+
+// newGeoJSONSyntaxError returns a SyntaxError for JSON which can't be read, with the
+// position where it was found
+func newGeoJSONSyntaxError(data []byte, err error) error {
+	offset := int64(0)
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxError):
+		offset = syntaxError.Offset
+	case errors.As(err, &typeError):
+		offset = typeError.Offset
+	}
+	offset = min(offset, int64(len(data)))
+
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+	return &SyntaxError{Offset: offset, Line: line, Column: column, Message: err.Error(), Err: err}
+}
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	Geometry   *geoJSONGeometry  `json:"geometry"`
+	Properties geoJSONProperties `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type geoJSONProperties struct {
+	Name         string         `json:"name,omitempty"`
+	Comment      string         `json:"cmt,omitempty"`
+	Description  string         `json:"desc,omitempty"`
+	Source       string         `json:"src,omitempty"`
+	Links        []geoJSONLink  `json:"links,omitempty"`
+	Symbol       string         `json:"sym,omitempty"`
+	Type         string         `json:"type,omitempty"`
+	Number       int            `json:"number,omitempty"`
+	Time         string         `json:"time,omitempty"`
+	Proximity    Metres         `json:"proximity,omitempty"`
+	Temperature  DegreesCelcius `json:"temperature,omitempty"`
+	Depth        Metres         `json:"depth,omitempty"`
+	DisplayColor DisplayColor   `json:"displayColor,omitempty"`
+
+	CoordinateProperties *geoJSONCoordinateProperties `json:"coordinateProperties,omitempty"`
+}
+
+type geoJSONLink struct {
+	Href string `json:"href"`
+	Text string `json:"text,omitempty"`
+	Type string `json:"type,omitempty"`
+}
+
+// geoJSONCoordinateProperties has a value for every point of every segment of a track,
+// which is null when the point doesn't have one. Arrays for which no point has a
+// value are left out.
+type geoJSONCoordinateProperties struct {
+	Times        [][]*string               `json:"times,omitempty"`
+	HeartRates   [][]*BeatsPerMinute       `json:"heartRates,omitempty"`
+	Cadences     [][]*RevolutionsPerMinute `json:"cadences,omitempty"`
+	Temperatures [][]*DegreesCelcius       `json:"temperatures,omitempty"`
+	Powers       [][]*Watts                `json:"powers,omitempty"`
+}
+
+// geoJSONPosition is a longitude, a latitude and an optional elevation
+type geoJSONPosition []float64
+
+func newWayPointFeature(w *WayPoint) geoJSONFeature {
+	properties := geoJSONProperties{
+		Name:        w.Name,
+		Comment:     w.Comment,
+		Description: w.Description,
+		Source:      w.Source,
+		Links:       newGeoJSONLinks(w.Links),
+		Symbol:      w.Symbol,
+		Type:        w.Type,
+		Time:        w.Timestamp.String(),
+	}
+	if extension := w.Extensions.WayPointExtensions; extension != nil {
+		properties.Proximity = extension.Proximity
+		properties.Temperature = extension.Temperature
+		properties.Depth = extension.Depth
+	}
+
+	position := newGeoJSONPositions([]Position{w}, []*float64{w.Elevation})
+	return newGeoJSONFeature(geoJSONPoint, position[0], properties)
+}
+
+func newRouteFeature(r *Route) geoJSONFeature {
+	properties := geoJSONProperties{
+		Name:        r.Name,
+		Comment:     r.Comment,
+		Description: r.Description,
+		Source:      r.Source,
+		Links:       newGeoJSONLinks(r.Links),
+		Type:        r.Type,
+		Number:      r.Number,
+	}
+	if extension := r.Extensions.RouteExtensions; extension != nil {
+		properties.DisplayColor = extension.DisplayColor
+	}
+
+	points := make([]Position, len(r.RoutePoints))
+	elevations := make([]*float64, len(r.RoutePoints))
+	for i := range r.RoutePoints {
+		points[i] = &r.RoutePoints[i]
+		elevations[i] = r.RoutePoints[i].Elevation
+	}
+	return newGeoJSONFeature(geoJSONLineString, newGeoJSONPositions(points, elevations), properties)
+}
+
+func newTrackFeature(t *Track, coordinates bool) geoJSONFeature {
+	properties := geoJSONProperties{
+		Name:        t.Name,
+		Comment:     t.Comment,
+		Description: t.Description,
+		Source:      t.Source,
+		Links:       newGeoJSONLinks(t.Links),
+		Type:        t.Type,
+		Number:      t.Number,
+	}
+	if t.Extensions != nil && t.Extensions.TrackExtensions != nil {
+		properties.DisplayColor = t.Extensions.TrackExtensions.DisplayColor
+	}
+	if start, _ := t.timeRange(); !start.IsZero() {
+		properties.Time = NewDateTime(start).String()
+	}
+
+	points, elevations := []Position{}, []*float64{}
+	for i := range t.TrackSegments {
+		for j := range t.TrackSegments[i].TrackPoint {
+			points = append(points, &t.TrackSegments[i].TrackPoint[j])
+			elevations = append(elevations, t.TrackSegments[i].TrackPoint[j].Elevation)
+		}
+	}
+	positions := newGeoJSONPositions(points, elevations)
+
+	lines := make([][]geoJSONPosition, len(t.TrackSegments))
+	for i := range t.TrackSegments {
+		n := len(t.TrackSegments[i].TrackPoint)
+		lines[i], positions = positions[:n:n], positions[n:]
+	}
+	if coordinates {
+		properties.CoordinateProperties = newGeoJSONCoordinateProperties(t)
+	}
+	return newGeoJSONFeature(geoJSONMultiLineString, lines, properties)
+}
+
+func newGeoJSONFeature(kind string, coordinates any, properties geoJSONProperties) geoJSONFeature {
+	data, _ := json.Marshal(coordinates)
+	return geoJSONFeature{
+		Type:       "Feature",
+		Geometry:   &geoJSONGeometry{Type: kind, Coordinates: data},
+		Properties: properties,
+	}
+}
+
+// newGeoJSONPositions returns the positions of points, with their elevation when they
+// have one
+func newGeoJSONPositions(points []Position, elevations []*float64) []geoJSONPosition {
+	positions := make([]geoJSONPosition, len(points))
+	for i, point := range points {
+		lat, lon := point.LatLon()
+		positions[i] = geoJSONPosition{float64(lon), float64(lat)}
+		if elevations[i] != nil {
+			positions[i] = append(positions[i], *elevations[i])
+		}
+	}
+	return positions
+}
+
+func newGeoJSONLinks(links []Link) []geoJSONLink {
+	out := []geoJSONLink{}
+	for _, link := range links {
+		out = append(out, geoJSONLink{Href: link.URL, Text: link.Text, Type: link.Type})
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func newGeoJSONCoordinateProperties(t *Track) *geoJSONCoordinateProperties {
+	c := &geoJSONCoordinateProperties{}
+	found := map[string]bool{}
+	for i := range t.TrackSegments {
+		points := t.TrackSegments[i].TrackPoint
+		times := make([]*string, len(points))
+		heartRates := make([]*BeatsPerMinute, len(points))
+		cadences := make([]*RevolutionsPerMinute, len(points))
+		temperatures := make([]*DegreesCelcius, len(points))
+		powers := make([]*Watts, len(points))
+
+		for j := range points {
+			values := sensors(points[j])
+			if time := points[j].Timestamp.String(); time != "" {
+				times[j], found["times"] = &time, true
+			}
+			if values.HeartRate != 0 {
+				heartRates[j], found["heartRates"] = &values.HeartRate, true
+			}
+			if values.Cadence != 0 {
+				cadences[j], found["cadences"] = &values.Cadence, true
+			}
+			if values.Temperature != 0 {
+				temperatures[j], found["temperatures"] = &values.Temperature, true
+			}
+			if power := points[j].power(); power != 0 {
+				powers[j], found["powers"] = &power, true
+			}
+		}
+
+		c.Times = append(c.Times, times)
+		c.HeartRates = append(c.HeartRates, heartRates)
+		c.Cadences = append(c.Cadences, cadences)
+		c.Temperatures = append(c.Temperatures, temperatures)
+		c.Powers = append(c.Powers, powers)
+	}
+
+	if !found["times"] {
+		c.Times = nil
+	}
+	if !found["heartRates"] {
+		c.HeartRates = nil
+	}
+	if !found["cadences"] {
+		c.Cadences = nil
+	}
+	if !found["temperatures"] {
+		c.Temperatures = nil
+	}
+	if !found["powers"] {
+		c.Powers = nil
+	}
+	return c
+}
+
+// addFeature adds the waypoints, route or track of a feature to the document
+func (g *GPX) addFeature(f geoJSONMembers) error {
+	geometry := geoJSONMembers{}
+	geoJSONMember(f, "geometry", &geometry)
+	members := geoJSONMembers{}
+	geoJSONMember(f, "properties", &members)
+	p := newGeoJSONPropertiesOf(members)
+	coordinates := geometry["coordinates"]
+
+	switch geometry.kind() {
+	case geoJSONPoint, geoJSONMultiPoint:
+		positions := []geoJSONPosition{}
+		var err error
+		if geometry.kind() == geoJSONPoint {
+			positions = append(positions, nil)
+			err = decodeCoordinates(coordinates, &positions[0])
+		} else {
+			err = decodeCoordinates(coordinates, &positions)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, position := range positions {
+			waypoint := WayPoint{
+				Name:        p.Name,
+				Comment:     p.Comment,
+				Description: p.Description,
+				Source:      p.Source,
+				Links:       p.links(),
+				Symbol:      p.Symbol,
+				Type:        p.Type,
+				Timestamp:   parseGeoJSONTime(p.Time),
+			}
+			if p.Proximity != 0 || p.Temperature != 0 || p.Depth != 0 {
+				waypoint.Extensions.WayPointExtensions = &WayPointExtension{Proximity: p.Proximity, Temperature: p.Temperature, Depth: p.Depth}
+			}
+			waypoint.Latitude, waypoint.Longitude, waypoint.Elevation = position.coordinates()
+			g.Waypoints = append(g.Waypoints, waypoint)
+		}
+
+	case geoJSONLineString:
+		positions := []geoJSONPosition{}
+		if err := decodeCoordinates(coordinates, &positions); err != nil {
+			return err
+		}
+		if p.CoordinateProperties != nil {
+			g.Tracks = append(g.Tracks, p.track([][]geoJSONPosition{positions}))
+			break
+		}
+
+		route := Route{
+			Name:        p.Name,
+			Comment:     p.Comment,
+			Description: p.Description,
+			Source:      p.Source,
+			Links:       p.links(),
+			Type:        p.Type,
+			Number:      p.Number,
+		}
+		if p.DisplayColor != "" {
+			route.Extensions.RouteExtensions = &RouteExtension{DisplayColor: p.DisplayColor}
+		}
+		for _, position := range positions {
+			point := RoutePoint{}
+			point.Latitude, point.Longitude, point.Elevation = position.coordinates()
+			route.RoutePoints = append(route.RoutePoints, point)
+		}
+		g.Routes = append(g.Routes, route)
+
+	case geoJSONMultiLineString:
+		lines := [][]geoJSONPosition{}
+		if err := decodeCoordinates(coordinates, &lines); err != nil {
+			return err
+		}
+		g.Tracks = append(g.Tracks, p.track(lines))
+	}
+	return nil
+}
+
+// track returns a track with the properties and a segment for each line
+func (p *geoJSONProperties) track(lines [][]geoJSONPosition) Track {
+	track := Track{
+		Name:        p.Name,
+		Comment:     p.Comment,
+		Description: p.Description,
+		Source:      p.Source,
+		Links:       p.links(),
+		Type:        p.Type,
+		Number:      p.Number,
+	}
+	if p.DisplayColor != "" {
+		track.Extensions = &TrackExtensions{TrackExtensions: &TrackExtension{DisplayColor: p.DisplayColor}}
+	}
+	for i, line := range lines {
+		segment := TrackSegment{}
+		for j, position := range line {
+			point := TrackPoint{}
+			point.Latitude, point.Longitude, point.Elevation = position.coordinates()
+			p.CoordinateProperties.apply(&point, i, j)
+			segment.TrackPoint = append(segment.TrackPoint, point)
+		}
+		track.TrackSegments = append(track.TrackSegments, segment)
+	}
+	return track
+}
+
+// newGeoJSONPropertiesOf reads the properties WriteGeoJSON writes from the members of
+// the properties of a feature, skipping those which don't have the expected type
+func newGeoJSONPropertiesOf(m geoJSONMembers) geoJSONProperties {
+	p := geoJSONProperties{}
+	geoJSONMember(m, "name", &p.Name)
+	geoJSONMember(m, "cmt", &p.Comment)
+	geoJSONMember(m, "desc", &p.Description)
+	geoJSONMember(m, "src", &p.Source)
+	geoJSONMember(m, "links", &p.Links)
+	geoJSONMember(m, "sym", &p.Symbol)
+	geoJSONMember(m, "type", &p.Type)
+	geoJSONMember(m, "number", &p.Number)
+	geoJSONMember(m, "time", &p.Time)
+	geoJSONMember(m, "proximity", &p.Proximity)
+	geoJSONMember(m, "temperature", &p.Temperature)
+	geoJSONMember(m, "depth", &p.Depth)
+	geoJSONMember(m, "displayColor", &p.DisplayColor)
+
+	coordinates := geoJSONMembers{}
+	if geoJSONMember(m, "coordinateProperties", &coordinates) && coordinates != nil {
+		c := &geoJSONCoordinateProperties{}
+		geoJSONArrays(coordinates, &c.Times, "times")
+		geoJSONArrays(coordinates, &c.HeartRates, "heartRates", "heart")
+		geoJSONArrays(coordinates, &c.Cadences, "cadences", "cadence")
+		geoJSONArrays(coordinates, &c.Temperatures, "temperatures", "temperature")
+		geoJSONArrays(coordinates, &c.Powers, "powers", "power")
+		p.CoordinateProperties = c
+	}
+	return p
+}
+
+// geoJSONArrays sets values to the first of the coordinate properties with one of the
+// keys which is an array for every line, or a flat array for a single line
+func geoJSONArrays[T any](m geoJSONMembers, values *[][]*T, keys ...string) {
+	for _, key := range keys {
+		if geoJSONMember(m, key, values) {
+			return
+		}
+		flat := []*T{}
+		if geoJSONMember(m, key, &flat) {
+			*values = [][]*T{flat}
+			return
+		}
+	}
+}
+
+// points returns the number of waypoints, route points and track points
+func (g *GPX) points() int {
+	n := len(g.Waypoints)
+	for i := range g.Routes {
+		n += len(g.Routes[i].RoutePoints)
+	}
+	for i := range g.Tracks {
+		for j := range g.Tracks[i].TrackSegments {
+			n += len(g.Tracks[i].TrackSegments[j].TrackPoint)
+		}
+	}
+	return n
+}
+
+func (p *geoJSONProperties) links() []Link {
+	links := []Link{}
+	for _, link := range p.Links {
+		links = append(links, Link{URL: link.Href, Text: link.Text, Type: link.Type})
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return links
+}
+
+// apply sets the values of a point from the coordinate properties at a segment and
+// point index
+func (c *geoJSONCoordinateProperties) apply(point *TrackPoint, segment, index int) {
+	if c == nil {
+		return
+	}
+
+	if value := geoJSONValue(c.Times, segment, index); value != nil {
+		point.Timestamp = parseGeoJSONTime(*value)
+	}
+
+	extension := TrackPointExtension{}
+	if value := geoJSONValue(c.HeartRates, segment, index); value != nil {
+		extension.HeartRate = *value
+	}
+	if value := geoJSONValue(c.Cadences, segment, index); value != nil {
+		extension.Cadence = *value
+	}
+	if value := geoJSONValue(c.Temperatures, segment, index); value != nil {
+		extension.Temperature = *value
+	}
+	power := Watts(0)
+	if value := geoJSONValue(c.Powers, segment, index); value != nil {
+		power = *value
+	}
+
+	if extension != (TrackPointExtension{}) || power != 0 {
+		point.Extensions = &TrackPointExtensions{Power: power}
+		if extension != (TrackPointExtension{}) {
+			point.Extensions.TrackPointExtensions = &extension
+		}
+	}
+}
+
+// geoJSONValue returns the value of a coordinate property at a segment and point index,
+// or nil if there is none
+func geoJSONValue[T any](values [][]*T, segment, index int) *T {
+	if segment >= len(values) || index >= len(values[segment]) {
+		return nil
+	}
+	return values[segment][index]
+}
+
+// decodeCoordinates reads the coordinates of a geometry, checking that every position
+// has a longitude and a latitude within their range
+func decodeCoordinates(data json.RawMessage, coordinates any) error {
+	if err := json.Unmarshal(data, coordinates); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCoordinate, err)
+	}
+
+	var positions []geoJSONPosition
+	switch c := coordinates.(type) {
+	case *geoJSONPosition:
+		positions = []geoJSONPosition{*c}
+	case *[]geoJSONPosition:
+		positions = *c
+	case *[][]geoJSONPosition:
+		for _, line := range *c {
+			positions = append(positions, line...)
+		}
+	}
+	for _, position := range positions {
+		if len(position) < 2 {
+			return fmt.Errorf("%w: position %v needs a longitude and a latitude", ErrInvalidCoordinate, []float64(position))
+		}
+		if position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+			return fmt.Errorf("%w: position %v is out of range", ErrInvalidCoordinate, []float64(position))
+		}
+	}
+	return nil
+}
+
+// coordinates returns the latitude, longitude and elevation of a position
+func (p geoJSONPosition) coordinates() (Latitude, Longitude, *float64) {
+	if len(p) > 2 {
+		return Latitude(p[1]), Longitude(p[0]), &p[2]
+	}
+	return Latitude(p[1]), Longitude(p[0]), nil
+}
+
+// parseGeoJSONTime parses a time property, which is left out when it is empty or not
+// a time
+func parseGeoJSONTime(value string) DateTime {
+	t, err := ParseDateTime(value)
+	if err != nil {
+		return DateTime{}
+	}
+	return t
+}
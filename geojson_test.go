@@ -0,0 +1,143 @@
+package gpx_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_WriteGeoJSON(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	elevation := 10.0
+	g := &gpx.GPX{
+		Waypoints: []gpx.WayPoint{{Latitude: 1, Longitude: 2, Name: "Summit", Symbol: "Flag"}},
+		Routes: []gpx.Route{{Name: "Loop", RoutePoints: []gpx.RoutePoint{
+			{Latitude: 1, Longitude: 2, Elevation: &elevation},
+			{Latitude: 3, Longitude: 4},
+		}}},
+		Tracks: []gpx.Track{{Name: "Ride", Type: "cycling", TrackSegments: []gpx.TrackSegment{
+			{TrackPoint: []gpx.TrackPoint{
+				withSensors(trackPoint(1, 2, 0, start), 100, 80, 0),
+				trackPoint(1.001, 2, 0, start.Add(time.Second)),
+			}},
+			{TrackPoint: []gpx.TrackPoint{withSensors(trackPoint(1.002, 2, 0, start.Add(time.Minute)), 110, 0, 0)}},
+		}}},
+	}
+	g.Waypoints[0].Extensions.WayPointExtensions = &gpx.WayPointExtension{Proximity: 50}
+
+	buffer := bytes.Buffer{}
+	require.Nil(t, gpx.WriteGeoJSON(&buffer, g))
+	assert.JSONEq(t, `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [2, 1]},
+			"properties": {"name": "Summit", "sym": "Flag", "proximity": 50}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[2, 1, 10], [4, 3]]},
+			"properties": {"name": "Loop"}},
+		{"type": "Feature", "geometry": {"type": "MultiLineString", "coordinates": [[[2, 1, 0], [2, 1.001, 0]], [[2, 1.002, 0]]]},
+			"properties": {"name": "Ride", "type": "cycling", "time": "2020-01-01T10:00:00Z"}}
+	]}`, buffer.String())
+
+	buffer.Reset()
+	require.Nil(t, gpx.WriteGeoJSON(&buffer, g, gpx.CoordinateProperties()))
+	assert.Contains(t, buffer.String(), `"coordinateProperties":{`+
+		`"times":[["2020-01-01T10:00:00Z","2020-01-01T10:00:01Z"],["2020-01-01T10:01:00Z"]],`+
+		`"heartRates":[[100,null],[110]],`+
+		`"cadences":[[80,null],[null]]}`)
+}
+
+func Test_ParseGeoJSON(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	track := gpx.Track{Name: "Ride", TrackSegments: []gpx.TrackSegment{{TrackPoint: []gpx.TrackPoint{
+		withSensors(trackPoint(1, 2, 5, start), 100, 80, 21),
+		trackPoint(1.001, 2, 6, start.Add(time.Second)),
+	}}}}
+	track.TrackSegments[0].TrackPoint[1].Extensions = &gpx.TrackPointExtensions{Power: 250}
+	g := &gpx.GPX{
+		Waypoints: []gpx.WayPoint{{Latitude: 1, Longitude: 2, Name: "Summit", Timestamp: gpx.NewDateTime(start)}},
+		Routes:    []gpx.Route{{Name: "Loop", RoutePoints: []gpx.RoutePoint{{Latitude: 1, Longitude: 2}, {Latitude: 3, Longitude: 4}}}},
+		Tracks:    []gpx.Track{track},
+	}
+
+	buffer := bytes.Buffer{}
+	require.Nil(t, gpx.WriteGeoJSON(&buffer, g, gpx.CoordinateProperties()))
+	parsed, err := gpx.ParseGeoJSON(&buffer)
+	require.Nil(t, err)
+
+	require.Len(t, parsed.Waypoints, 1)
+	assert.Equal(t, "Summit", parsed.Waypoints[0].Name)
+	assert.True(t, start.Equal(parsed.Waypoints[0].Timestamp.Time))
+	require.Len(t, parsed.Routes, 1)
+	assert.Equal(t, g.Routes[0].RoutePoints, parsed.Routes[0].RoutePoints)
+
+	require.Len(t, parsed.Tracks, 1)
+	points := parsed.Tracks[0].TrackSegments[0].TrackPoint
+	require.Len(t, points, 2)
+	assert.Equal(t, gpx.Latitude(1.001), points[1].Latitude)
+	assert.Equal(t, 6.0, *points[1].Elevation)
+	assert.True(t, start.Add(time.Second).Equal(points[1].Timestamp.Time))
+	assert.Equal(t, gpx.BeatsPerMinute(100), points[0].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.DegreesCelcius(21), points[0].Extensions.TrackPointExtensions.Temperature)
+	assert.Nil(t, points[1].Extensions.TrackPointExtensions)
+	assert.Equal(t, gpx.Watts(250), points[1].Extensions.Power)
+
+	// A single feature, a bare geometry and geometries which can't be converted
+	parsed, err = gpx.ParseGeoJSON(strings.NewReader(`{"type": "Feature", "properties": {"name": "Hut"},
+		"geometry": {"type": "MultiPoint", "coordinates": [[2, 1], [4, 3, 100]]}}`))
+	require.Nil(t, err)
+	require.Len(t, parsed.Waypoints, 2)
+	assert.Equal(t, "Hut", parsed.Waypoints[1].Name)
+	assert.Equal(t, 100.0, *parsed.Waypoints[1].Elevation)
+
+	parsed, err = gpx.ParseGeoJSON(strings.NewReader(`{"type": "LineString", "coordinates": [[2, 1], [4, 3]]}`))
+	require.Nil(t, err)
+	assert.Len(t, parsed.Routes[0].RoutePoints, 2)
+
+	parsed, err = gpx.ParseGeoJSON(strings.NewReader(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [0, 1], [0, 0]]]}},
+		{"type": "Feature", "geometry": null}]}`))
+	require.Nil(t, err)
+	assert.Empty(t, parsed.Waypoints)
+	assert.Empty(t, parsed.Tracks)
+
+	_, err = gpx.ParseGeoJSON(strings.NewReader(`{"type": "Point", "coordinates": [2]}`))
+	assert.True(t, errors.Is(err, gpx.ErrInvalidCoordinate))
+	_, err = gpx.ParseGeoJSON(strings.NewReader(`{"type": "Point", "coordinates": [500, 2]}`))
+	assert.True(t, errors.Is(err, gpx.ErrInvalidCoordinate))
+	_, err = gpx.ParseGeoJSON(strings.NewReader("{\n\"type\": \"Point\",, }"))
+	assert.True(t, errors.Is(err, gpx.ErrSyntax))
+	syntaxError := &gpx.SyntaxError{}
+	require.True(t, errors.As(err, &syntaxError))
+	assert.Equal(t, 2, syntaxError.Line)
+	_, err = gpx.ParseGeoJSON(strings.NewReader(`{"type": "MultiPoint", "coordinates": [[2, 1], [4, 3]]}`), gpx.MaxPoints(1))
+	assert.True(t, errors.Is(err, gpx.ErrLimitExceeded))
+}
+
+func Test_ParseGeoJSONFromOtherTools(t *testing.T) {
+	// Properties of other types than those WriteGeoJSON writes are skipped, and a
+	// LineString with flat coordinate properties, as togeojson writes tracks, is a track
+	g, err := gpx.ParseGeoJSON(strings.NewReader(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {"name": "Hut", "number": "A1", "time": 1577872800000, "sym": null},
+			"geometry": {"type": "Point", "coordinates": [2, 1]}},
+		{"type": "Feature", "properties": {"name": "Morning Ride", "type": "cycling", "time": "noon",
+			"coordinateProperties": {"times": ["2020-01-01T10:00:00Z", "2020-01-01T10:00:01Z"], "heart": [100, 101]}},
+			"geometry": {"type": "LineString", "coordinates": [[2, 1, 10], [2, 1.001, 11]]}},
+		"not a feature"
+	]}`))
+	require.Nil(t, err)
+
+	require.Len(t, g.Waypoints, 1)
+	assert.Equal(t, "Hut", g.Waypoints[0].Name)
+	assert.True(t, g.Waypoints[0].Timestamp.IsZero())
+	assert.Empty(t, g.Routes)
+	require.Len(t, g.Tracks, 1)
+	assert.Equal(t, "Morning Ride", g.Tracks[0].Name)
+	points := g.Tracks[0].TrackSegments[0].TrackPoint
+	require.Len(t, points, 2)
+	assert.Equal(t, "2020-01-01T10:00:01Z", points[1].Timestamp.String())
+	assert.Equal(t, gpx.BeatsPerMinute(101), points[1].Extensions.TrackPointExtensions.HeartRate)
+}
@@ -1,59 +1,125 @@
 package gpx
 
 import (
+	"bytes"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	xml "github.com/Zauberstuhl/go-xml"
 )
 
 // ParseFile takes a file and parses it
-func ParseFile(fileName string) (*GPX, error) {
+func ParseFile(fileName string, opts ...ReadOption) (*GPX, error) {
 	g := GPX{}
 
 	bytes, err := os.ReadFile(fileName)
 	if err != nil {
-		return &g, err
+		return &g, &IOError{Err: err}
 	}
 
-	err = Parse(bytes, &g)
+	err = Parse(bytes, &g, opts...)
 	if err != nil {
 		return &g, err
 	}
 	return &g, nil
 }
 
-// Parse bytes of xml
-func Parse(bytes []byte, g *GPX) error {
-	err := xml.Unmarshal(bytes, g)
+// Parse bytes of xml, which can be compressed with gzip
+func Parse(data []byte, g *GPX, opts ...ReadOption) error {
+	reader, err := decompress(data)
+	if err != nil {
+		return err
+	}
+
+	decoder := NewDecoder(reader, opts...)
+	err = decoder.Decode(g)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// Write GPX file
-func Write(g *GPX, fileName string) error {
-	output, err := xml.MarshalIndent(g, "", "    ")
+// ParseReader reads a document, which can be compressed with gzip
+func ParseReader(r io.Reader, opts ...ReadOption) (*GPX, error) {
+	g := GPX{}
+
+	input, err := decompressReader(r)
 	if err != nil {
+		return &g, err
+	}
+
+	decoder := NewDecoder(input, opts...)
+	if err := decoder.Decode(&g); err != nil {
+		return &g, err
+	}
+	return &g, nil
+}
+
+// WriteTo writes the document with its XML header, indented with four spaces unless
+// the options say otherwise
+func WriteTo(w io.Writer, g *GPX, opts ...WriteOption) error {
+	encoder := NewEncoder(w, append(writeDefaults[:len(writeDefaults):len(writeDefaults)], opts...)...)
+	return encoder.Encode(g)
+}
+
+// Write GPX file, compressed with gzip when the name ends with .gz. The .gpx extension
+// is added to names which end with neither gpx nor .gz.
+func Write(g *GPX, fileName string, opts ...WriteOption) error {
+	o := newWriteOptions(opts)
+
+	buf := bytes.Buffer{}
+	if err := WriteTo(&buf, g, opts...); err != nil {
 		return err
 	}
+	fileData := buf.Bytes()
 
-	fileData := append([]byte(xml.Header), output...)
 	var path string
-
-	if strings.HasSuffix(fileName, "gpx") {
+	if strings.HasSuffix(fileName, "gpx") || isGzipName(fileName) {
 		path = fileName
 	} else {
 		path = fileName + ".gpx"
 	}
 
-	err = os.WriteFile(path, fileData, 0755)
+	if isGzipName(path) {
+		var err error
+		fileData, err = gzipData(fileData)
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.atomic {
+		return writeAtomic(path, fileData, o.fileMode)
+	}
+	return os.WriteFile(path, fileData, o.fileMode)
+}
+
+// writeAtomic writes data to a temporary file next to path and renames it to path
+func writeAtomic(path string, data []byte, mode os.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	return nil
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Chmod(mode); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
 }
 
 // Comments from http://www.topografix.com/GPX/1/1/
@@ -61,6 +127,7 @@ func Write(g *GPX, fileName string) error {
 // https://www8.garmin.com/xmlschemas/GpxExtensions/v3/GpxExtensionsv3.xsd
 // https://www8.garmin.com/xmlschemas/WaypointExtensionv1.xsd
 // https://www8.garmin.com/xmlschemas/TrackPointExtensionv1.xsd
+// https://www8.garmin.com/xmlschemas/TrackPointExtensionv2.xsd
 
 // GPX is the root element
 type GPX struct {
@@ -81,7 +148,7 @@ type Metadata struct {
 	Author      *Person     `xml:"author,omitempty"`
 	Copyright   *Copyright  `xml:"copyright,omitempty"`
 	Links       []Link      `xml:"link,omitempty"`
-	Timestamp   string      `xml:"time,omitempty"`
+	Timestamp   DateTime    `xml:"time,omitempty"`
 	Keywords    string      `xml:"keywords,omitempty"`
 	Bounds      *Bounds     `xml:"bounds,omitempty"`
 	Extensions  *Extensions `xml:"extensions,omitempty"`
@@ -92,8 +159,8 @@ type WayPoint struct {
 	XMLName                       xml.Name           `xml:"wpt"`
 	Latitude                      Latitude           `xml:"lat,attr"`
 	Longitude                     Longitude          `xml:"lon,attr"`
-	Elevation                     float64            `xml:"ele,omitempty"`
-	Timestamp                     string             `xml:"time,omitempty"`
+	Elevation                     *float64           `xml:"ele,omitempty"`
+	Timestamp                     DateTime           `xml:"time,omitempty"`
 	MagneticVariation             Degrees            `xml:"magvar,omitempty"`
 	GeoIDHeight                   float64            `xml:"geoidheight,omitempty"`
 	Name                          string             `xml:"name,omitempty"`
@@ -108,7 +175,7 @@ type WayPoint struct {
 	HorizontalDilutionOfPrecision float64            `xml:"hdop,omitempty"`
 	VerticalDilutionOfPrecision   float64            `xml:"vdop,omitempty"`
 	PositionDilutionOfPrecision   float64            `xml:"pdop,omitempty"`
-	AgeOfGpsData                  float64            `xml:"ageofgpsdata,omitempty"`
+	AgeOfGpsData                  float64            `xml:"ageofdgpsdata,omitempty"`
 	DifferentialGPSID             DGPSStation        `xml:"dgpsid,omitempty"`
 	Extensions                    WayPointExtensions `xml:"extensions,omitempty"`
 }
@@ -132,8 +199,8 @@ type RoutePoint struct {
 	XMLName                       xml.Name             `xml:"rtept"`
 	Latitude                      Latitude             `xml:"lat,attr"`
 	Longitude                     Longitude            `xml:"lon,attr"`
-	Elevation                     float64              `xml:"ele,omitempty"`
-	Timestamp                     string               `xml:"time,omitempty"`
+	Elevation                     *float64             `xml:"ele,omitempty"`
+	Timestamp                     DateTime             `xml:"time,omitempty"`
 	MagneticVariation             Degrees              `xml:"magvar,omitempty"`
 	GeoIDHeight                   float64              `xml:"geoidheight,omitempty"`
 	Name                          string               `xml:"name,omitempty"`
@@ -148,7 +215,7 @@ type RoutePoint struct {
 	HorizontalDilutionOfPrecision float64              `xml:"hdop,omitempty"`
 	VerticalDilutionOfPrecision   float64              `xml:"vdop,omitempty"`
 	PositionDilutionOfPrecision   float64              `xml:"pdop,omitempty"`
-	AgeOfGpsData                  float64              `xml:"ageofgpsdata,omitempty"`
+	AgeOfGpsData                  float64              `xml:"ageofdgpsdata,omitempty"`
 	DifferentialGPSID             DGPSStation          `xml:"dgpsid,omitempty"`
 	Extensions                    RoutePointExtensions `xml:"extensions,omitempty"`
 }
@@ -179,8 +246,8 @@ type TrackPoint struct {
 	XMLName                       xml.Name              `xml:"trkpt"`
 	Latitude                      Latitude              `xml:"lat,attr"`
 	Longitude                     Longitude             `xml:"lon,attr"`
-	Elevation                     float64               `xml:"ele,omitempty"`
-	Timestamp                     string                `xml:"time,omitempty"`
+	Elevation                     *float64              `xml:"ele,omitempty"`
+	Timestamp                     DateTime              `xml:"time,omitempty"`
 	MagneticVariation             Degrees               `xml:"magvar,omitempty"`
 	GeoIDHeight                   float64               `xml:"geoidheight,omitempty"`
 	Name                          string                `xml:"name,omitempty"`
@@ -195,7 +262,7 @@ type TrackPoint struct {
 	HorizontalDilutionOfPrecision float64               `xml:"hdop,omitempty"`
 	VerticalDilutionOfPrecision   float64               `xml:"vdop,omitempty"`
 	PositionDilutionOfPrecision   float64               `xml:"pdop,omitempty"`
-	AgeOfGpsData                  float64               `xml:"ageofgpsdata,omitempty"`
+	AgeOfGpsData                  float64               `xml:"ageofdgpsdata,omitempty"`
 	DifferentialGPSID             DGPSStation           `xml:"dgpsid,omitempty"`
 	Extensions                    *TrackPointExtensions `xml:"extensions,omitempty"`
 }
@@ -236,8 +303,8 @@ type Point struct {
 	XMLName   xml.Name  `xml:"pt"`
 	Latitude  Latitude  `xml:"lat,attr"`
 	Longitude Longitude `xml:"lon,attr"`
-	Elevation float64   `xml:"ele,omitempty"`
-	Timestamp string    `xml:"time,omitempty"`
+	Elevation *float64  `xml:"ele,omitempty"`
+	Timestamp DateTime  `xml:"time,omitempty"`
 }
 
 // PointSegment is a sequence of Points
@@ -285,33 +352,127 @@ type DGPSStation int
 
 // Extensions extend GPX by adding your own elements from another schema
 type Extensions struct {
-	XMLName xml.Name `xml:"extensions"`
+	XMLName xml.Name     `xml:"extensions"`
+	Unknown []RawElement `xml:",any"`
 }
 
 type Decoder struct {
 	decoder *xml.Decoder
+
+	// tracker follows the position of the decoder to describe errors
+	tracker *tracker
+
+	// validator checks the tokens read by decoder with the Strict option
+	validator *validator
+
+	// version10 is set once the root element of a GPX 1.0 file has been read
+	version10 bool
 }
 
-func NewDecoder(r io.Reader) Decoder {
-	return Decoder{
-		decoder: xml.NewDecoder(r),
+func NewDecoder(r io.Reader, opts ...ReadOption) Decoder {
+	o := newReadOptions(opts)
+	t, tokens := newTokenReader(r, o, gpxDialect)
+
+	dec := Decoder{tracker: t}
+	if o.strict {
+		dec.validator = newValidator(tokens, t)
+		tokens = dec.validator
 	}
+	dec.decoder = xml.NewTokenDecoder(tokens)
+	return dec
 }
 
+// Decode reads the next GPX document. GPX 1.0 files are detected from the version
+// attribute and converted to GPX 1.1.
+// Errors are one of SyntaxError, UnsupportedVersionError, ValueError, CoordinateError
+// or IOError, or ValidationError with the Strict option. With the limits set by options,
+// they can also be a LimitError or the error of the context.
 func (dec *Decoder) Decode(v *GPX) error {
-	return dec.decoder.Decode(v)
+	if err := dec.decode(v); err != nil {
+		return dec.tracker.wrap(err)
+	}
+	return dec.invalid()
+}
+
+func (dec *Decoder) decode(v *GPX) error {
+	start, err := dec.root()
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(start); err != nil {
+		return err
+	}
+	if !isVersion10(start) {
+		return dec.decoder.DecodeElement(v, &start)
+	}
+
+	legacy := gpx10{}
+	if err := dec.decoder.DecodeElement(&legacy, &start); err != nil {
+		return err
+	}
+	*v = legacy.convert()
+	return nil
+}
+
+// invalid returns the issues found since it was last called with the Strict option
+func (dec *Decoder) invalid() error {
+	if dec.validator == nil {
+		return nil
+	}
+	if issues := dec.validator.take(); len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// newTokenReader returns the tracker of r, and a reader of its tokens in a dialect
+// which stops at the limits set by the options
+func newTokenReader(r io.Reader, o readOptions, d dialect) (*tracker, xml.TokenReader) {
+	t := newTracker(r, d)
+	t.position.limit = o.maxBytes
+
+	var tokens xml.TokenReader = t
+	if o.limited() {
+		tokens = newLimiter(tokens, o, d.points)
+	}
+	return t, tokens
+}
+
+// root skips the prolog and returns the start of the root element
+func (dec *Decoder) root() (xml.StartElement, error) {
+	for {
+		token, err := dec.decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
 }
 
 type Encoder struct {
+	writer  io.Writer
 	encoder *xml.Encoder
+	options writeOptions
 }
 
-func NewEncoder(w io.Writer) Encoder {
+func NewEncoder(w io.Writer, opts ...WriteOption) Encoder {
+	o := newWriteOptions(opts)
+	encoder := xml.NewEncoder(w)
+	encoder.Indent(o.prefix, o.indent)
 	return Encoder{
-		encoder: xml.NewEncoder(w),
+		writer:  w,
+		encoder: encoder,
+		options: o,
 	}
 }
 
 func (enc *Encoder) Encode(v *GPX) error {
-	return enc.encoder.Encode(v)
+	if enc.options.header {
+		if _, err := io.WriteString(enc.writer, xml.Header); err != nil {
+			return err
+		}
+	}
+	return enc.encoder.Encode(enc.options.document(v))
 }
@@ -0,0 +1,471 @@
+package gpx
+
+import (
+	"strings"
+
+	xml "github.com/Zauberstuhl/go-xml"
+)
+
+// GPX 1.0 has no metadata element, keeps a single url and urlname instead of links,
+// and records speed and course directly on points. Files using it are read into the
+// same GPX 1.1 structs, with speed and course of track points moving to
+// TrackPointExtension, and can be written back as 1.0 with the GPX10 option.
+// http://www.topografix.com/GPX/1/0/gpx.xsd
+
+// GPX10Namespace is the namespace of GPX 1.0
+const GPX10Namespace = "http://www.topografix.com/GPX/1/0"
+
+var gpx10Namespace = namespace{"", GPX10Namespace, "http://www.topografix.com/GPX/1/0/gpx.xsd"}
+
+// gpx10 is the root element of a GPX 1.0 file
+type gpx10 struct {
+	XMLName     xml.Name  `xml:"gpx"`
+	Version     string    `xml:"version,attr"`
+	Creator     string    `xml:"creator,attr"`
+	Name        string    `xml:"name,omitempty"`
+	Description string    `xml:"desc,omitempty"`
+	Author      string    `xml:"author,omitempty"`
+	Email       string    `xml:"email,omitempty"`
+	URL         string    `xml:"url,omitempty"`
+	URLName     string    `xml:"urlname,omitempty"`
+	Timestamp   DateTime  `xml:"time,omitempty"`
+	Keywords    string    `xml:"keywords,omitempty"`
+	Bounds      *Bounds   `xml:"bounds,omitempty"`
+	Waypoints   []point10 `xml:"wpt,omitempty"`
+	Routes      []route10 `xml:"rte,omitempty"`
+	Tracks      []track10 `xml:"trk,omitempty"`
+}
+
+// route10 is a route in GPX 1.0
+type route10 struct {
+	Name        string    `xml:"name,omitempty"`
+	Comment     string    `xml:"cmt,omitempty"`
+	Description string    `xml:"desc,omitempty"`
+	Source      string    `xml:"src,omitempty"`
+	URL         string    `xml:"url,omitempty"`
+	URLName     string    `xml:"urlname,omitempty"`
+	Number      int       `xml:"number,omitempty"`
+	RoutePoints []point10 `xml:"rtept"`
+}
+
+// track10 is a track in GPX 1.0
+type track10 struct {
+	Name          string      `xml:"name,omitempty"`
+	Comment       string      `xml:"cmt,omitempty"`
+	Description   string      `xml:"desc,omitempty"`
+	Source        string      `xml:"src,omitempty"`
+	URL           string      `xml:"url,omitempty"`
+	URLName       string      `xml:"urlname,omitempty"`
+	Number        int         `xml:"number,omitempty"`
+	TrackSegments []segment10 `xml:"trkseg"`
+}
+
+// segment10 is a track segment in GPX 1.0
+type segment10 struct {
+	TrackPoints []point10 `xml:"trkpt"`
+}
+
+// point10 is a waypoint, route point or track point in GPX 1.0
+type point10 struct {
+	Latitude                      Latitude         `xml:"lat,attr"`
+	Longitude                     Longitude        `xml:"lon,attr"`
+	Elevation                     *float64         `xml:"ele,omitempty"`
+	Timestamp                     DateTime         `xml:"time,omitempty"`
+	Course                        *Degrees         `xml:"course,omitempty"`
+	Speed                         *MetresPerSecond `xml:"speed,omitempty"`
+	MagneticVariation             Degrees          `xml:"magvar,omitempty"`
+	GeoIDHeight                   float64          `xml:"geoidheight,omitempty"`
+	Name                          string           `xml:"name,omitempty"`
+	Comment                       string           `xml:"cmt,omitempty"`
+	Description                   string           `xml:"desc,omitempty"`
+	Source                        string           `xml:"src,omitempty"`
+	URL                           string           `xml:"url,omitempty"`
+	URLName                       string           `xml:"urlname,omitempty"`
+	Symbol                        string           `xml:"sym,omitempty"`
+	Type                          string           `xml:"type,omitempty"`
+	Fix                           Fix              `xml:"fix,omitempty"`
+	Sat                           int              `xml:"sat,omitempty"`
+	HorizontalDilutionOfPrecision float64          `xml:"hdop,omitempty"`
+	VerticalDilutionOfPrecision   float64          `xml:"vdop,omitempty"`
+	PositionDilutionOfPrecision   float64          `xml:"pdop,omitempty"`
+	AgeOfGpsData                  float64          `xml:"ageofdgpsdata,omitempty"`
+	DifferentialGPSID             DGPSStation      `xml:"dgpsid,omitempty"`
+}
+
+// isVersion10 tells if the root element is from a GPX 1.0 file
+func isVersion10(start xml.StartElement) bool {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "version" {
+			return strings.TrimSpace(attr.Value) == "1.0"
+		}
+	}
+	return false
+}
+
+// MarshalXML writes the gpx root element of a GPX 1.0 file
+func (g gpx10) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain gpx10
+
+	g.Version = "1.0"
+	start.Name = xml.Name{Local: "gpx"}
+	start.Attr = append(start.Attr,
+		xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: gpx10Namespace.uri},
+		xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: xsiNamespace},
+		xml.Attr{Name: xml.Name{Local: "xsi:schemaLocation"}, Value: gpx10Namespace.uri + " " + gpx10Namespace.schema},
+	)
+	return e.EncodeElement(plain(g), start)
+}
+
+// convert turns a GPX 1.0 file into GPX 1.1
+func (g *gpx10) convert() GPX {
+	out := GPX{
+		Version:  "1.1",
+		Creator:  g.Creator,
+		Metadata: g.metadata(),
+	}
+	for i := range g.Waypoints {
+		out.Waypoints = append(out.Waypoints, g.Waypoints[i].wayPoint())
+	}
+	for i := range g.Routes {
+		out.Routes = append(out.Routes, g.Routes[i].route())
+	}
+	for i := range g.Tracks {
+		out.Tracks = append(out.Tracks, g.Tracks[i].track())
+	}
+	return out
+}
+
+// metadata gathers the fields which GPX 1.1 moved into the metadata element
+func (g *gpx10) metadata() Metadata {
+	m := Metadata{
+		Name:        g.Name,
+		Description: g.Description,
+		Links:       links10(g.URL, g.URLName),
+		Timestamp:   g.Timestamp,
+		Keywords:    g.Keywords,
+		Bounds:      g.Bounds,
+	}
+	if g.Author != "" || g.Email != "" {
+		m.Author = &Person{Name: g.Author}
+		if id, domain, ok := strings.Cut(g.Email, "@"); ok {
+			m.Author.Email = Email{ID: id, Domain: domain}
+		}
+	}
+	return m
+}
+
+// field returns the field of the root element which a child element is decoded into,
+// or nil for the children GPX 1.1 didn't move into the metadata element
+func (g *gpx10) field(name string) any {
+	switch name {
+	case "name":
+		return &g.Name
+	case "desc":
+		return &g.Description
+	case "author":
+		return &g.Author
+	case "email":
+		return &g.Email
+	case "url":
+		return &g.URL
+	case "urlname":
+		return &g.URLName
+	case "time":
+		return &g.Timestamp
+	case "keywords":
+		return &g.Keywords
+	case "bounds":
+		g.Bounds = &Bounds{}
+		return g.Bounds
+	}
+	return nil
+}
+
+// hasMetadata tells if any of the fields moved into the metadata element is set
+func (g *gpx10) hasMetadata() bool {
+	return g.Name != "" || g.Description != "" || g.Author != "" || g.Email != "" ||
+		g.URL != "" || g.URLName != "" || !g.Timestamp.IsZero() || g.Keywords != "" || g.Bounds != nil
+}
+
+func (r *route10) route() Route {
+	out := Route{
+		Name:        r.Name,
+		Comment:     r.Comment,
+		Description: r.Description,
+		Source:      r.Source,
+		Links:       links10(r.URL, r.URLName),
+		Number:      r.Number,
+	}
+	for i := range r.RoutePoints {
+		out.RoutePoints = append(out.RoutePoints, r.RoutePoints[i].routePoint())
+	}
+	return out
+}
+
+func (t *track10) track() Track {
+	out := Track{
+		Name:        t.Name,
+		Comment:     t.Comment,
+		Description: t.Description,
+		Source:      t.Source,
+		Links:       links10(t.URL, t.URLName),
+		Number:      t.Number,
+	}
+	for i := range t.TrackSegments {
+		segment := TrackSegment{}
+		for j := range t.TrackSegments[i].TrackPoints {
+			segment.TrackPoint = append(segment.TrackPoint, t.TrackSegments[i].TrackPoints[j].trackPoint())
+		}
+		out.TrackSegments = append(out.TrackSegments, segment)
+	}
+	return out
+}
+
+func (p *point10) wayPoint() WayPoint {
+	out := WayPoint{
+		Latitude:                      p.Latitude,
+		Longitude:                     p.Longitude,
+		Elevation:                     p.Elevation,
+		Timestamp:                     p.Timestamp,
+		MagneticVariation:             p.MagneticVariation,
+		GeoIDHeight:                   p.GeoIDHeight,
+		Name:                          p.Name,
+		Comment:                       p.Comment,
+		Description:                   p.Description,
+		Source:                        p.Source,
+		Links:                         links10(p.URL, p.URLName),
+		Symbol:                        p.Symbol,
+		Type:                          p.Type,
+		Fix:                           p.Fix,
+		Sat:                           p.Sat,
+		HorizontalDilutionOfPrecision: p.HorizontalDilutionOfPrecision,
+		VerticalDilutionOfPrecision:   p.VerticalDilutionOfPrecision,
+		PositionDilutionOfPrecision:   p.PositionDilutionOfPrecision,
+		AgeOfGpsData:                  p.AgeOfGpsData,
+		DifferentialGPSID:             p.DifferentialGPSID,
+	}
+	return out
+}
+
+func (p *point10) routePoint() RoutePoint {
+	out := RoutePoint{
+		Latitude:                      p.Latitude,
+		Longitude:                     p.Longitude,
+		Elevation:                     p.Elevation,
+		Timestamp:                     p.Timestamp,
+		MagneticVariation:             p.MagneticVariation,
+		GeoIDHeight:                   p.GeoIDHeight,
+		Name:                          p.Name,
+		Comment:                       p.Comment,
+		Description:                   p.Description,
+		Source:                        p.Source,
+		Links:                         links10(p.URL, p.URLName),
+		Symbol:                        p.Symbol,
+		Type:                          p.Type,
+		Fix:                           p.Fix,
+		Sat:                           p.Sat,
+		HorizontalDilutionOfPrecision: p.HorizontalDilutionOfPrecision,
+		VerticalDilutionOfPrecision:   p.VerticalDilutionOfPrecision,
+		PositionDilutionOfPrecision:   p.PositionDilutionOfPrecision,
+		AgeOfGpsData:                  p.AgeOfGpsData,
+		DifferentialGPSID:             p.DifferentialGPSID,
+	}
+	return out
+}
+
+func (p *point10) trackPoint() TrackPoint {
+	out := TrackPoint{
+		Latitude:                      p.Latitude,
+		Longitude:                     p.Longitude,
+		Elevation:                     p.Elevation,
+		Timestamp:                     p.Timestamp,
+		MagneticVariation:             p.MagneticVariation,
+		GeoIDHeight:                   p.GeoIDHeight,
+		Name:                          p.Name,
+		Comment:                       p.Comment,
+		Description:                   p.Description,
+		Source:                        p.Source,
+		Links:                         links10(p.URL, p.URLName),
+		Symbol:                        p.Symbol,
+		Type:                          p.Type,
+		Fix:                           p.Fix,
+		Sat:                           p.Sat,
+		HorizontalDilutionOfPrecision: p.HorizontalDilutionOfPrecision,
+		VerticalDilutionOfPrecision:   p.VerticalDilutionOfPrecision,
+		PositionDilutionOfPrecision:   p.PositionDilutionOfPrecision,
+		AgeOfGpsData:                  p.AgeOfGpsData,
+		DifferentialGPSID:             p.DifferentialGPSID,
+	}
+	if p.Speed != nil || p.Course != nil {
+		out.Extensions = &TrackPointExtensions{TrackPointExtensions: &TrackPointExtension{
+			Speed:  p.Speed,
+			Course: p.Course,
+		}}
+	}
+	return out
+}
+
+// wayPoint10 converts a waypoint to GPX 1.0
+func wayPoint10(p *WayPoint) point10 {
+	out := point10{
+		Latitude:                      p.Latitude,
+		Longitude:                     p.Longitude,
+		Elevation:                     p.Elevation,
+		Timestamp:                     p.Timestamp,
+		MagneticVariation:             p.MagneticVariation,
+		GeoIDHeight:                   p.GeoIDHeight,
+		Name:                          p.Name,
+		Comment:                       p.Comment,
+		Description:                   p.Description,
+		Source:                        p.Source,
+		Symbol:                        p.Symbol,
+		Type:                          p.Type,
+		Fix:                           p.Fix,
+		Sat:                           p.Sat,
+		HorizontalDilutionOfPrecision: p.HorizontalDilutionOfPrecision,
+		VerticalDilutionOfPrecision:   p.VerticalDilutionOfPrecision,
+		PositionDilutionOfPrecision:   p.PositionDilutionOfPrecision,
+		AgeOfGpsData:                  p.AgeOfGpsData,
+		DifferentialGPSID:             p.DifferentialGPSID,
+	}
+	out.URL, out.URLName = url10(p.Links)
+	return out
+}
+
+// routePoint10 converts a route point to GPX 1.0
+func routePoint10(p *RoutePoint) point10 {
+	out := point10{
+		Latitude:                      p.Latitude,
+		Longitude:                     p.Longitude,
+		Elevation:                     p.Elevation,
+		Timestamp:                     p.Timestamp,
+		MagneticVariation:             p.MagneticVariation,
+		GeoIDHeight:                   p.GeoIDHeight,
+		Name:                          p.Name,
+		Comment:                       p.Comment,
+		Description:                   p.Description,
+		Source:                        p.Source,
+		Symbol:                        p.Symbol,
+		Type:                          p.Type,
+		Fix:                           p.Fix,
+		Sat:                           p.Sat,
+		HorizontalDilutionOfPrecision: p.HorizontalDilutionOfPrecision,
+		VerticalDilutionOfPrecision:   p.VerticalDilutionOfPrecision,
+		PositionDilutionOfPrecision:   p.PositionDilutionOfPrecision,
+		AgeOfGpsData:                  p.AgeOfGpsData,
+		DifferentialGPSID:             p.DifferentialGPSID,
+	}
+	out.URL, out.URLName = url10(p.Links)
+	return out
+}
+
+// trackPoint10 converts a track point to GPX 1.0, with its speed and course
+func trackPoint10(p *TrackPoint) point10 {
+	out := point10{
+		Latitude:                      p.Latitude,
+		Longitude:                     p.Longitude,
+		Elevation:                     p.Elevation,
+		Timestamp:                     p.Timestamp,
+		MagneticVariation:             p.MagneticVariation,
+		GeoIDHeight:                   p.GeoIDHeight,
+		Name:                          p.Name,
+		Comment:                       p.Comment,
+		Description:                   p.Description,
+		Source:                        p.Source,
+		Symbol:                        p.Symbol,
+		Type:                          p.Type,
+		Fix:                           p.Fix,
+		Sat:                           p.Sat,
+		HorizontalDilutionOfPrecision: p.HorizontalDilutionOfPrecision,
+		VerticalDilutionOfPrecision:   p.VerticalDilutionOfPrecision,
+		PositionDilutionOfPrecision:   p.PositionDilutionOfPrecision,
+		AgeOfGpsData:                  p.AgeOfGpsData,
+		DifferentialGPSID:             p.DifferentialGPSID,
+	}
+	out.URL, out.URLName = url10(p.Links)
+	if p.Extensions != nil && p.Extensions.TrackPointExtensions != nil {
+		out.Speed = p.Extensions.TrackPointExtensions.Speed
+		out.Course = p.Extensions.TrackPointExtensions.Course
+	}
+	return out
+}
+
+// links10 turns the url and urlname of GPX 1.0 into links
+func links10(url, name string) []Link {
+	if url == "" && name == "" {
+		return nil
+	}
+	return []Link{{URL: url, Text: name}}
+}
+
+// url10 returns the url and urlname of GPX 1.0 from the first link
+func url10(links []Link) (string, string) {
+	if len(links) == 0 {
+		return "", ""
+	}
+	return links[0].URL, links[0].Text
+}
+
+// toGPX10 turns a GPX 1.1 file into GPX 1.0. Extensions are dropped, except for the
+// speed and course of track points.
+func toGPX10(g *GPX) *gpx10 {
+	out := &gpx10{
+		Creator:     g.Creator,
+		Name:        g.Metadata.Name,
+		Description: g.Metadata.Description,
+		Timestamp:   g.Metadata.Timestamp,
+		Keywords:    g.Metadata.Keywords,
+		Bounds:      g.Metadata.Bounds,
+	}
+	out.URL, out.URLName = url10(g.Metadata.Links)
+	if author := g.Metadata.Author; author != nil {
+		out.Author = author.Name
+		if author.Email.ID != "" && author.Email.Domain != "" {
+			out.Email = author.Email.ID + "@" + author.Email.Domain
+		}
+	}
+
+	for i := range g.Waypoints {
+		p := &g.Waypoints[i]
+		out.Waypoints = append(out.Waypoints, wayPoint10(p))
+	}
+
+	for i := range g.Routes {
+		r := &g.Routes[i]
+		route := route10{
+			Name:        r.Name,
+			Comment:     r.Comment,
+			Description: r.Description,
+			Source:      r.Source,
+			Number:      r.Number,
+		}
+		route.URL, route.URLName = url10(r.Links)
+		for j := range r.RoutePoints {
+			p := &r.RoutePoints[j]
+			route.RoutePoints = append(route.RoutePoints, routePoint10(p))
+		}
+		out.Routes = append(out.Routes, route)
+	}
+
+	for i := range g.Tracks {
+		t := &g.Tracks[i]
+		track := track10{
+			Name:        t.Name,
+			Comment:     t.Comment,
+			Description: t.Description,
+			Source:      t.Source,
+			Number:      t.Number,
+		}
+		track.URL, track.URLName = url10(t.Links)
+		for j := range t.TrackSegments {
+			segment := segment10{}
+			for k := range t.TrackSegments[j].TrackPoint {
+				segment.TrackPoints = append(segment.TrackPoints, trackPoint10(&t.TrackSegments[j].TrackPoint[k]))
+			}
+			track.TrackSegments = append(track.TrackSegments, segment)
+		}
+		out.Tracks = append(out.Tracks, track)
+	}
+
+	return out
+}
@@ -0,0 +1,113 @@
+package gpx_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_ParseGPX10(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/gpx10.gpx")
+	require.Nil(t, err)
+
+	assert.Equal(t, "1.1", g.Version)
+	assert.Equal(t, "GPSBabel - http://www.gpsbabel.org", g.Creator)
+	assert.Equal(t, "Morning walk", g.Metadata.Name)
+	assert.Equal(t, "Around the lake", g.Metadata.Description)
+	assert.Equal(t, "walk, lake", g.Metadata.Keywords)
+	assert.Equal(t, "2011-09-22T18:56:51Z", g.Metadata.Timestamp.String())
+	require.NotNil(t, g.Metadata.Author)
+	assert.Equal(t, "Jane Doe", g.Metadata.Author.Name)
+	assert.Equal(t, "jane", g.Metadata.Author.Email.ID)
+	assert.Equal(t, "example.com", g.Metadata.Author.Email.Domain)
+	require.Len(t, g.Metadata.Links, 1)
+	assert.Equal(t, "http://example.com/walk", g.Metadata.Links[0].URL)
+	assert.Equal(t, "Walk page", g.Metadata.Links[0].Text)
+	require.NotNil(t, g.Metadata.Bounds)
+	assert.Equal(t, gpx.Longitude(-122.327), g.Metadata.Bounds.MinimumLongitude)
+
+	require.Len(t, g.Waypoints, 1)
+	assert.Equal(t, "Start", g.Waypoints[0].Name)
+	assert.Equal(t, "Start page", g.Waypoints[0].Links[0].Text)
+
+	require.Len(t, g.Routes, 1)
+	assert.Equal(t, 1, g.Routes[0].Number)
+	require.Len(t, g.Routes[0].RoutePoints, 2)
+	assert.Equal(t, "R2", g.Routes[0].RoutePoints[1].Name)
+
+	require.Len(t, g.Tracks, 1)
+	assert.Equal(t, "Lake loop", g.Tracks[0].Name)
+	assert.Equal(t, "http://example.com/track", g.Tracks[0].Links[0].URL)
+	points := g.Tracks[0].TrackSegments[0].TrackPoint
+	require.Len(t, points, 2)
+	assert.Equal(t, 4.46, *points[0].Elevation)
+	assert.Equal(t, 7, points[0].Sat)
+	require.NotNil(t, points[0].Extensions)
+	assert.Equal(t, gpx.MetresPerSecond(1.25), *points[0].Extensions.TrackPointExtensions.Speed)
+	assert.Equal(t, gpx.Degrees(92.5), *points[0].Extensions.TrackPointExtensions.Course)
+	assert.Nil(t, points[1].Extensions)
+}
+
+func Test_StreamGPX10(t *testing.T) {
+	file, err := os.Open("./samples/gpx10.gpx")
+	require.Nil(t, err)
+	defer file.Close()
+
+	decoder := gpx.NewDecoder(file)
+	kinds := []gpx.ElementKind{}
+	var metadata *gpx.Metadata
+	var points []*gpx.TrackPoint
+	for element, err := range decoder.Elements() {
+		require.Nil(t, err)
+		kinds = append(kinds, element.Kind)
+		switch element.Kind {
+		case gpx.MetadataElement:
+			metadata = element.Metadata
+		case gpx.TrackPointElement:
+			assert.Equal(t, "Track page", element.Track.Links[0].Text)
+			points = append(points, element.TrackPoint)
+		}
+	}
+
+	assert.Equal(t, []gpx.ElementKind{
+		gpx.MetadataElement, gpx.WayPointElement, gpx.RouteElement,
+		gpx.TrackElement, gpx.TrackPointElement, gpx.TrackPointElement,
+	}, kinds)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "Morning walk", metadata.Name)
+	require.Len(t, points, 2)
+	assert.Equal(t, gpx.MetresPerSecond(1.25), *points[0].Extensions.TrackPointExtensions.Speed)
+}
+
+func Test_EncodeGPX10(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/gpx10.gpx")
+	require.Nil(t, err)
+
+	buf := bytes.Buffer{}
+	encoder := gpx.NewEncoder(&buf, gpx.GPX10())
+	require.Nil(t, encoder.Encode(g))
+
+	out := buf.String()
+	assert.Contains(t, out, `version="1.0"`)
+	assert.Contains(t, out, `xmlns="http://www.topografix.com/GPX/1/0"`)
+	assert.Contains(t, out, `<author>Jane Doe</author>`)
+	assert.Contains(t, out, `<email>jane@example.com</email>`)
+	assert.Contains(t, out, `<speed>1.25</speed>`)
+	assert.NotContains(t, out, `metadata`)
+	assert.NotContains(t, out, `extensions`)
+
+	namespaces := namespacesOf(t, buf.Bytes())
+	assert.Equal(t, gpx.GPX10Namespace, namespaces["trkpt"])
+
+	p := gpx.GPX{}
+	require.Nil(t, gpx.Parse(buf.Bytes(), &p))
+	assert.Equal(t, g.Metadata.Name, p.Metadata.Name)
+	assert.Equal(t, g.Metadata.Links, p.Metadata.Links)
+	assert.Equal(t, g.Waypoints, p.Waypoints)
+	assert.Equal(t, g.Routes, p.Routes)
+	assert.Equal(t, g.Tracks, p.Tracks)
+}
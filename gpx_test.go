@@ -1,8 +1,14 @@
 package gpx_test
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,6 +26,7 @@ func Test_InvalidXMLParser(t *testing.T) {
 	file := "./samples/error.gpx"
 	_, err := gpx.ParseFile(file)
 	assert.Contains(t, err.Error(), "invalid syntax")
+	assert.True(t, errors.Is(err, gpx.ErrInvalidValue))
 }
 
 func Test_MapboxParser(t *testing.T) {
@@ -29,22 +36,22 @@ func Test_MapboxParser(t *testing.T) {
 
 	assert.Equal(t, "connect.garmin.com", g.Metadata.Links[0].URL)
 	assert.Equal(t, "Garmin Connect", g.Metadata.Links[0].Text)
-	assert.Equal(t, "2012-10-24T23:22:51.000Z", g.Metadata.Timestamp)
+	assert.Equal(t, "2012-10-24T23:22:51.000Z", g.Metadata.Timestamp.String())
 
 	assert.Equal(t, "Untitled", g.Tracks[0].Name)
 
 	point0 := g.Tracks[0].TrackSegments[0].TrackPoint[0]
 	assert.Equal(t, gpx.Longitude(-77.02016168273985), point0.Longitude)
 	assert.Equal(t, gpx.Latitude(38.92747367732227), point0.Latitude)
-	assert.Equal(t, 25.600000381469727, point0.Elevation)
-	assert.Equal(t, "2012-10-24T23:29:40.000Z", point0.Timestamp)
+	assert.Equal(t, 25.600000381469727, *point0.Elevation)
+	assert.Equal(t, "2012-10-24T23:29:40.000Z", point0.Timestamp.String())
 	assert.Equal(t, gpx.BeatsPerMinute(130), point0.Extensions.TrackPointExtensions.HeartRate)
 
 	point1 := g.Tracks[0].TrackSegments[0].TrackPoint[1]
 	assert.Equal(t, gpx.Longitude(-77.02014584094286), point1.Longitude)
 	assert.Equal(t, gpx.Latitude(38.927609380334616), point1.Latitude)
-	assert.Equal(t, 35.599998474121094, point1.Elevation)
-	assert.Equal(t, "2012-10-24T23:30:00.000Z", point1.Timestamp)
+	assert.Equal(t, 35.599998474121094, *point1.Elevation)
+	assert.Equal(t, "2012-10-24T23:30:00.000Z", point1.Timestamp.String())
 	assert.Equal(t, gpx.BeatsPerMinute(134), point1.Extensions.TrackPointExtensions.HeartRate)
 }
 
@@ -64,7 +71,7 @@ func Test_SpecParser(t *testing.T) {
 	assert.Equal(t, "copyrightAuthor", g.Metadata.Copyright.Author)
 	assert.Equal(t, 2019, g.Metadata.Copyright.Year)
 	assert.Equal(t, "http://url.tld", g.Metadata.Copyright.License)
-	assert.Equal(t, "2018-02-26T22:58:34Z", g.Metadata.Timestamp)
+	assert.Equal(t, "2018-02-26T22:58:34Z", g.Metadata.Timestamp.String())
 	assert.Equal(t, "keywords", g.Metadata.Keywords)
 	assert.Equal(t, gpx.Latitude(-90.0), g.Metadata.Bounds.MinimumLatitude)
 	assert.Equal(t, gpx.Longitude(-180.0), g.Metadata.Bounds.MinimumLongitude)
@@ -73,7 +80,7 @@ func Test_SpecParser(t *testing.T) {
 
 	assert.Equal(t, gpx.Latitude(90.0), g.Waypoints[0].Latitude)
 	assert.Equal(t, gpx.Longitude(180.0), g.Waypoints[0].Longitude)
-	assert.Equal(t, 12.0, g.Waypoints[0].Elevation)
+	assert.Equal(t, 12.0, *g.Waypoints[0].Elevation)
 	assert.Equal(t, gpx.Degrees(360.0), g.Waypoints[0].MagneticVariation)
 	assert.Equal(t, 0.0, g.Waypoints[0].GeoIDHeight)
 	assert.Equal(t, gpx.Fix("3d"), g.Waypoints[0].Fix)
@@ -85,7 +92,7 @@ func Test_SpecParser(t *testing.T) {
 	assert.Equal(t, gpx.DGPSStation(1023), g.Waypoints[0].DifferentialGPSID)
 
 	assert.Equal(t, "routeName", g.Routes[0].Name)
-	assert.Equal(t, 10.0, g.Routes[0].RoutePoints[0].Elevation)
+	assert.Equal(t, 10.0, *g.Routes[0].RoutePoints[0].Elevation)
 	assert.Equal(t, "trackName", g.Tracks[0].Name)
 	assert.Equal(t, "trackType", g.Tracks[0].Type)
 	assert.Equal(t, "pointName", g.Tracks[0].TrackSegments[0].TrackPoint[0].Name)
@@ -101,7 +108,7 @@ func Test_StLouisZooParser(t *testing.T) {
 	assert.Equal(t, "wizardone, using GeoTours", g.Metadata.Author.Name)
 	assert.Equal(t, "http://www.geovative.com/view?t=GEIF", g.Metadata.Author.Link.URL)
 	assert.Equal(t, "St Louis Zoo sample", g.Metadata.Author.Link.Text)
-	assert.Equal(t, "2008-02-26T19:49:13", g.Metadata.Timestamp)
+	assert.Equal(t, "2008-02-26T19:49:13", g.Metadata.Timestamp.String())
 	assert.Contains(t, g.Metadata.Keywords, "Audio tour guide")
 
 	assert.Equal(t, gpx.Latitude(38.63473), g.Waypoints[0].Latitude)
@@ -126,14 +133,14 @@ func Test_StravaSampleParser(t *testing.T) {
 	g, err := gpx.ParseFile(file)
 	assert.Nil(t, err)
 
-	assert.Equal(t, "2018-02-26T22:58:34Z", g.Metadata.Timestamp)
+	assert.Equal(t, "2018-02-26T22:58:34Z", g.Metadata.Timestamp.String())
 
 	assert.Equal(t, "Really bad GPS", g.Tracks[0].Name)
-	assert.Equal(t, 16.6, g.Tracks[0].TrackSegments[0].TrackPoint[0].Elevation)
+	assert.Equal(t, 16.6, *g.Tracks[0].TrackSegments[0].TrackPoint[0].Elevation)
 	assert.Equal(t, gpx.DegreesCelcius(28), g.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.Temperature)
 	assert.Equal(t, gpx.BeatsPerMinute(95), g.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.HeartRate)
 	assert.Equal(t, gpx.RevolutionsPerMinute(0), g.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.TrackPointExtensions.Cadence)
-	assert.Equal(t, 16.4, g.Tracks[0].TrackSegments[0].TrackPoint[1].Elevation)
+	assert.Equal(t, 16.4, *g.Tracks[0].TrackSegments[0].TrackPoint[1].Elevation)
 	assert.Equal(t, gpx.DegreesCelcius(28), g.Tracks[0].TrackSegments[0].TrackPoint[1].Extensions.TrackPointExtensions.Temperature)
 	assert.Equal(t, gpx.BeatsPerMinute(95), g.Tracks[0].TrackSegments[0].TrackPoint[1].Extensions.TrackPointExtensions.HeartRate)
 	assert.Equal(t, gpx.RevolutionsPerMinute(0), g.Tracks[0].TrackSegments[0].TrackPoint[1].Extensions.TrackPointExtensions.Cadence)
@@ -144,17 +151,17 @@ func Test_WikipediaParser(t *testing.T) {
 	g, err := gpx.ParseFile(file)
 	assert.Nil(t, err)
 
-	assert.Equal(t, "2009-10-17T22:58:43Z", g.Metadata.Timestamp)
+	assert.Equal(t, "2009-10-17T22:58:43Z", g.Metadata.Timestamp.String())
 	assert.Equal(t, "http://www.garmin.com", g.Metadata.Links[0].URL)
 	assert.Equal(t, "Garmin International", g.Metadata.Links[0].Text)
 
 	assert.Equal(t, "Example GPX Document", g.Tracks[0].Name)
 	assert.Equal(t, gpx.Latitude(47.644548), g.Tracks[0].TrackSegments[0].TrackPoint[0].Latitude)
-	assert.Equal(t, 4.46, g.Tracks[0].TrackSegments[0].TrackPoint[0].Elevation)
+	assert.Equal(t, 4.46, *g.Tracks[0].TrackSegments[0].TrackPoint[0].Elevation)
 	assert.Equal(t, gpx.Latitude(47.644548), g.Tracks[0].TrackSegments[0].TrackPoint[1].Latitude)
-	assert.Equal(t, 4.94, g.Tracks[0].TrackSegments[0].TrackPoint[1].Elevation)
+	assert.Equal(t, 4.94, *g.Tracks[0].TrackSegments[0].TrackPoint[1].Elevation)
 	assert.Equal(t, gpx.Latitude(47.644548), g.Tracks[0].TrackSegments[0].TrackPoint[2].Latitude)
-	assert.Equal(t, 6.87, g.Tracks[0].TrackSegments[0].TrackPoint[2].Elevation)
+	assert.Equal(t, 6.87, *g.Tracks[0].TrackSegments[0].TrackPoint[2].Elevation)
 }
 
 func Test_WriteGPX(t *testing.T) {
@@ -188,17 +195,17 @@ func TestDecodeGpx(t *testing.T) {
 	err = decoder.Decode(g)
 	require.Nil(t, err)
 
-	assert.Equal(t, "2009-10-17T22:58:43Z", g.Metadata.Timestamp)
+	assert.Equal(t, "2009-10-17T22:58:43Z", g.Metadata.Timestamp.String())
 	assert.Equal(t, "http://www.garmin.com", g.Metadata.Links[0].URL)
 	assert.Equal(t, "Garmin International", g.Metadata.Links[0].Text)
 
 	assert.Equal(t, "Example GPX Document", g.Tracks[0].Name)
 	assert.Equal(t, gpx.Latitude(47.644548), g.Tracks[0].TrackSegments[0].TrackPoint[0].Latitude)
-	assert.Equal(t, 4.46, g.Tracks[0].TrackSegments[0].TrackPoint[0].Elevation)
+	assert.Equal(t, 4.46, *g.Tracks[0].TrackSegments[0].TrackPoint[0].Elevation)
 	assert.Equal(t, gpx.Latitude(47.644548), g.Tracks[0].TrackSegments[0].TrackPoint[1].Latitude)
-	assert.Equal(t, 4.94, g.Tracks[0].TrackSegments[0].TrackPoint[1].Elevation)
+	assert.Equal(t, 4.94, *g.Tracks[0].TrackSegments[0].TrackPoint[1].Elevation)
 	assert.Equal(t, gpx.Latitude(47.644548), g.Tracks[0].TrackSegments[0].TrackPoint[2].Latitude)
-	assert.Equal(t, 6.87, g.Tracks[0].TrackSegments[0].TrackPoint[2].Elevation)
+	assert.Equal(t, 6.87, *g.Tracks[0].TrackSegments[0].TrackPoint[2].Elevation)
 }
 
 func Test_WriteGPX_EncoderDecoder(t *testing.T) {
@@ -225,3 +232,68 @@ func Test_WriteGPX_EncoderDecoder(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "1.1", p.Version)
 }
+
+func Test_ParseReader(t *testing.T) {
+	file, err := os.Open("./samples/mapbox.gpx")
+	require.Nil(t, err)
+	defer file.Close()
+
+	g, err := gpx.ParseReader(file)
+	require.Nil(t, err)
+	assert.Equal(t, "Untitled", g.Tracks[0].Name)
+
+	buf := bytes.Buffer{}
+	writer := gzip.NewWriter(&buf)
+	require.Nil(t, gpx.WriteTo(writer, g))
+	require.Nil(t, writer.Close())
+
+	p, err := gpx.ParseReader(&buf)
+	require.Nil(t, err)
+	assert.Equal(t, g.Tracks, p.Tracks)
+
+	_, err = gpx.ParseReader(strings.NewReader(""))
+	assert.NotNil(t, err)
+}
+
+func Test_WriteTo(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/mapbox.gpx")
+	require.Nil(t, err)
+
+	buf := bytes.Buffer{}
+	require.Nil(t, gpx.WriteTo(&buf, g))
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header+"<gpx "))
+	assert.Contains(t, buf.String(), "\n    <metadata>")
+
+	buf.Reset()
+	require.Nil(t, gpx.WriteTo(&buf, g, gpx.Header(false), gpx.Indent("", ""), gpx.Creator("my app")))
+	assert.True(t, strings.HasPrefix(buf.String(), "<gpx "))
+	assert.NotContains(t, buf.String(), "\n")
+	assert.Contains(t, buf.String(), `creator="my app"`)
+	assert.NotEqual(t, "my app", g.Creator)
+}
+
+func Test_WriteOptions(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/mapbox.gpx")
+	require.Nil(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ride.gpx")
+
+	require.Nil(t, gpx.Write(g, path))
+	info, err := os.Stat(path)
+	require.Nil(t, err)
+	assert.Zero(t, info.Mode().Perm()&0111)
+
+	require.Nil(t, gpx.Write(g, path, gpx.Atomic(), gpx.FileMode(0600)))
+	info, err = os.Stat(path)
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+
+	p, err := gpx.ParseFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, g.Tracks, p.Tracks)
+}
@@ -0,0 +1,132 @@
+package gpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	xml "github.com/Zauberstuhl/go-xml"
+)
+
+// ErrLimitExceeded is matched by every LimitError
+var ErrLimitExceeded = errors.New("gpx: limit exceeded")
+
+// LimitError is returned when a document goes over one of the limits set by MaxBytes,
+// MaxElements, MaxPoints or MaxDepth
+type LimitError struct {
+	// Limit is one of "bytes", "elements", "points" or "depth"
+	Limit string
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("gpx: document exceeds the maximum of %d %s", e.Max, e.Limit)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// Context stops decoding with the error of ctx once it is done. The context is
+// checked between tokens, so a read which blocks is not interrupted.
+func Context(ctx context.Context) ReadOption {
+	return func(o *readOptions) {
+		o.ctx = ctx
+	}
+}
+
+// MaxBytes limits the size of the document, after decompression
+func MaxBytes(n int64) ReadOption {
+	return func(o *readOptions) {
+		o.maxBytes = n
+	}
+}
+
+// MaxElements limits the number of XML elements in the document
+func MaxElements(n int) ReadOption {
+	return func(o *readOptions) {
+		o.maxElements = n
+	}
+}
+
+// MaxPoints limits the number of waypoints, route points and track points in the
+// document, or of trackpoints in a TCX document
+func MaxPoints(n int) ReadOption {
+	return func(o *readOptions) {
+		o.maxPoints = n
+	}
+}
+
+// MaxDepth limits how deeply elements are nested, the root element being at depth 1
+func MaxDepth(n int) ReadOption {
+	return func(o *readOptions) {
+		o.maxDepth = n
+	}
+}
+
+// limiter stops reading tokens once the context is done or a limit is exceeded
+type limiter struct {
+	tokens      xml.TokenReader
+	ctx         context.Context
+	maxElements int
+	maxPoints   int
+	maxDepth    int
+	// pointNames are the names of the elements counted as points
+	pointNames []string
+
+	elements int
+	points   int
+	depth    int
+}
+
+// limited tells if any of the limits checked on tokens is set
+func (o *readOptions) limited() bool {
+	return o.ctx != nil || o.maxElements > 0 || o.maxPoints > 0 || o.maxDepth > 0
+}
+
+func newLimiter(tokens xml.TokenReader, o readOptions, pointNames []string) *limiter {
+	return &limiter{
+		tokens:      tokens,
+		ctx:         o.ctx,
+		maxElements: o.maxElements,
+		maxPoints:   o.maxPoints,
+		maxDepth:    o.maxDepth,
+		pointNames:  pointNames,
+	}
+}
+
+// Token returns the next token unless a limit has been reached
+func (l *limiter) Token() (xml.Token, error) {
+	if l.ctx != nil {
+		if err := l.ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := l.tokens.Token()
+	if err != nil {
+		return token, err
+	}
+
+	switch t := token.(type) {
+	case xml.StartElement:
+		l.elements++
+		l.depth++
+		if slices.Contains(l.pointNames, t.Name.Local) {
+			l.points++
+		}
+
+		switch {
+		case l.maxElements > 0 && l.elements > l.maxElements:
+			return nil, &LimitError{Limit: "elements", Max: int64(l.maxElements)}
+		case l.maxPoints > 0 && l.points > l.maxPoints:
+			return nil, &LimitError{Limit: "points", Max: int64(l.maxPoints)}
+		case l.maxDepth > 0 && l.depth > l.maxDepth:
+			return nil, &LimitError{Limit: "depth", Max: int64(l.maxDepth)}
+		}
+	case xml.EndElement:
+		l.depth--
+	}
+	return token, nil
+}
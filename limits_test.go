@@ -0,0 +1,69 @@
+package gpx_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_DecodeLimits(t *testing.T) {
+	data, err := os.ReadFile("./samples/mapbox.gpx")
+	require.Nil(t, err)
+
+	g := gpx.GPX{}
+	require.Nil(t, gpx.Parse(data, &g, gpx.MaxBytes(int64(len(data))), gpx.MaxPoints(1000), gpx.MaxDepth(10)))
+	points := len(g.Tracks[0].TrackSegments[0].TrackPoint)
+
+	tests := []struct {
+		option gpx.ReadOption
+		limit  string
+	}{
+		{gpx.MaxBytes(int64(len(data) - 1)), "bytes"},
+		{gpx.MaxBytes(100), "bytes"},
+		{gpx.MaxPoints(points - 1), "points"},
+		{gpx.MaxElements(10), "elements"},
+		{gpx.MaxDepth(4), "depth"},
+	}
+	for _, test := range tests {
+		err := gpx.Parse(data, &gpx.GPX{}, test.option)
+		assert.True(t, errors.Is(err, gpx.ErrLimitExceeded))
+
+		limitErr := &gpx.LimitError{}
+		require.True(t, errors.As(err, &limitErr))
+		assert.Equal(t, test.limit, limitErr.Limit)
+	}
+}
+
+func Test_DecodeContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := gpx.ParseFile("./samples/mapbox.gpx", gpx.Context(ctx))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	file, err := os.Open("./samples/mapbox.gpx")
+	require.Nil(t, err)
+	defer file.Close()
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	decoder := gpx.NewDecoder(file, gpx.Context(ctx), gpx.Strict())
+	points := 0
+	for element, err := range decoder.TrackPoints() {
+		if err != nil {
+			assert.True(t, errors.Is(err, context.Canceled))
+			break
+		}
+		points++
+		if element.PointIndex == 9 {
+			cancel()
+		}
+	}
+	assert.Equal(t, 10, points)
+}
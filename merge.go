@@ -0,0 +1,172 @@
+package gpx
+
+import (
+	"sort"
+	"time"
+)
+
+// MergeOption changes how Merge combines files
+type MergeOption func(*mergeOptions)
+
+type mergeOptions struct {
+	joinTracks bool
+	joinGap    time.Duration
+}
+
+// JoinTracks makes Merge join tracks which start at most maxGap after the track before
+// them ends into a single track, with the segments of each of them
+func JoinTracks(maxGap time.Duration) MergeOption {
+	return func(o *mergeOptions) {
+		o.joinTracks = true
+		o.joinGap = maxGap
+	}
+}
+
+// Merge combines several files, like the files of one ride recorded by several
+// devices, into a new document. Tracks are sorted by the time of their first point,
+// with tracks without any time at the end, routes are kept in order, and identical
+// waypoints, with the same position, elevation, name and time, are only kept once.
+// Metadata is taken from the first file which has each field, except for the time
+// which is the earliest one, the links which are all kept once, and the bounds which
+// are computed from the merged points.
+func Merge(files []*GPX, opts ...MergeOption) *GPX {
+	o := mergeOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	out := &GPX{Version: "1.1"}
+
+	for _, g := range files {
+		if g == nil {
+			continue
+		}
+		if out.Creator == "" {
+			out.Creator = g.Creator
+		}
+		mergeMetadata(&out.Metadata, &g.Metadata)
+		for _, waypoint := range g.Waypoints {
+			if !containsFunc(out.Waypoints, waypoint, sameWayPoint) {
+				out.Waypoints = append(out.Waypoints, waypoint)
+			}
+		}
+		out.Routes = append(out.Routes, g.Routes...)
+		out.Tracks = append(out.Tracks, g.Tracks...)
+	}
+
+	starts := make(map[*Track]time.Time, len(out.Tracks))
+	tracks := make([]*Track, len(out.Tracks))
+	for i := range out.Tracks {
+		tracks[i] = &out.Tracks[i]
+		starts[tracks[i]], _ = tracks[i].timeRange()
+	}
+	sort.SliceStable(tracks, func(i, j int) bool {
+		a, b := starts[tracks[i]], starts[tracks[j]]
+		return !a.IsZero() && (b.IsZero() || a.Before(b))
+	})
+
+	sorted := make([]Track, 0, len(tracks))
+	for _, track := range tracks {
+		if n := len(sorted); o.joinTracks && n > 0 && contiguous(&sorted[n-1], track, o.joinGap) {
+			sorted[n-1].TrackSegments = append(sorted[n-1].TrackSegments, track.TrackSegments...)
+			continue
+		}
+		copied := *track
+		copied.TrackSegments = append([]TrackSegment(nil), track.TrackSegments...)
+		sorted = append(sorted, copied)
+	}
+	out.Tracks = sorted
+
+	out.Metadata.Bounds = out.ComputeBounds()
+	return out
+}
+
+// mergeMetadata fills the fields of metadata which are still empty from another one
+func mergeMetadata(metadata, other *Metadata) {
+	if metadata.Name == "" {
+		metadata.Name = other.Name
+	}
+	if metadata.Description == "" {
+		metadata.Description = other.Description
+	}
+	if metadata.Author == nil {
+		metadata.Author = other.Author
+	}
+	if metadata.Copyright == nil {
+		metadata.Copyright = other.Copyright
+	}
+	if metadata.Keywords == "" {
+		metadata.Keywords = other.Keywords
+	}
+	if metadata.Extensions == nil {
+		metadata.Extensions = other.Extensions
+	}
+	if !other.Timestamp.IsZero() && (metadata.Timestamp.IsZero() || other.Timestamp.Before(metadata.Timestamp.Time)) {
+		metadata.Timestamp = other.Timestamp
+	}
+	for _, link := range other.Links {
+		if !containsFunc(metadata.Links, link, sameLink) {
+			metadata.Links = append(metadata.Links, link)
+		}
+	}
+}
+
+// contiguous tells if a track starts at most maxGap after another one ends
+func contiguous(before, after *Track, maxGap time.Duration) bool {
+	_, end := before.timeRange()
+	start, _ := after.timeRange()
+	if end.IsZero() || start.IsZero() {
+		return false
+	}
+	return start.Sub(end) <= maxGap
+}
+
+// timeRange returns the earliest and the latest time of the points of the track, which
+// are zero when none of them have a time
+func (t *Track) timeRange() (time.Time, time.Time) {
+	var start, end time.Time
+	for i := range t.TrackSegments {
+		for j := range t.TrackSegments[i].TrackPoint {
+			at := t.TrackSegments[i].TrackPoint[j].Timestamp.Time
+			if at.IsZero() {
+				continue
+			}
+			if start.IsZero() || at.Before(start) {
+				start = at
+			}
+			if end.IsZero() || at.After(end) {
+				end = at
+			}
+		}
+	}
+	return start, end
+}
+
+// containsFunc tells if a list has a value equal to another one according to equal
+func containsFunc[T any](list []T, value T, equal func(a, b *T) bool) bool {
+	for i := range list {
+		if equal(&list[i], &value) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameWayPoint tells if two waypoints are at the same place and time with the same
+// name, whatever the precision of the time as it was written
+func sameWayPoint(a, b *WayPoint) bool {
+	return a.Latitude == b.Latitude && a.Longitude == b.Longitude && sameElevation(a.Elevation, b.Elevation) &&
+		a.Name == b.Name && a.Timestamp.Equal(b.Timestamp.Time)
+}
+
+// sameElevation tells if two elevations are both missing or equal
+func sameElevation(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// sameLink tells if two links have the same URL, text and type
+func sameLink(a, b *Link) bool {
+	return a.URL == b.URL && a.Text == b.Text && a.Type == b.Type
+}
@@ -0,0 +1,87 @@
+package gpx_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_Merge(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	track := func(name string, offset time.Duration, lat float64) gpx.Track {
+		return gpx.Track{Name: name, TrackSegments: []gpx.TrackSegment{{TrackPoint: []gpx.TrackPoint{
+			trackPoint(lat, 0, 0, start.Add(offset)),
+			trackPoint(lat, 0.01, 0, start.Add(offset+time.Hour)),
+		}}}}
+	}
+	shop := gpx.WayPoint{Latitude: 1, Longitude: 1, Name: "Shop"}
+	home := gpx.Link{URL: "http://example.com"}
+
+	morning := &gpx.GPX{
+		Creator:   "Edge",
+		Metadata:  gpx.Metadata{Timestamp: gpx.NewDateTime(start), Links: []gpx.Link{home}},
+		Waypoints: []gpx.WayPoint{shop},
+		Tracks:    []gpx.Track{track("Morning", 0, 0)},
+	}
+	afternoon := &gpx.GPX{
+		Creator:   "Watch",
+		Metadata:  gpx.Metadata{Name: "Ride", Timestamp: gpx.NewDateTime(start.Add(-time.Hour)), Links: []gpx.Link{home, {URL: "http://example.org"}}},
+		Waypoints: []gpx.WayPoint{shop, {Latitude: 2, Longitude: 2}},
+		Routes:    []gpx.Route{{Name: "Back"}},
+		Tracks:    []gpx.Track{track("Evening", 5*time.Hour, 0.02), track("Afternoon", 90*time.Minute, -0.01)},
+	}
+
+	merged := gpx.Merge([]*gpx.GPX{morning, nil, afternoon})
+	assert.Equal(t, "1.1", merged.Version)
+	assert.Equal(t, "Edge", merged.Creator)
+	assert.Equal(t, "Ride", merged.Metadata.Name)
+	assert.Equal(t, start.Add(-time.Hour), merged.Metadata.Timestamp.Time)
+	assert.Len(t, merged.Metadata.Links, 2)
+	assert.Len(t, merged.Waypoints, 2)
+	assert.Len(t, merged.Routes, 1)
+
+	names := []string{}
+	for _, track := range merged.Tracks {
+		names = append(names, track.Name)
+	}
+	assert.Equal(t, []string{"Morning", "Afternoon", "Evening"}, names)
+
+	require.NotNil(t, merged.Metadata.Bounds)
+	assert.Equal(t, gpx.Latitude(-0.01), merged.Metadata.Bounds.MinimumLatitude)
+	assert.Equal(t, gpx.Latitude(2), merged.Metadata.Bounds.MaximumLatitude)
+	assert.Len(t, morning.Metadata.Links, 1)
+
+	joined := gpx.Merge([]*gpx.GPX{afternoon, morning}, gpx.JoinTracks(time.Hour))
+	require.Len(t, joined.Tracks, 2)
+	assert.Equal(t, "Morning", joined.Tracks[0].Name)
+	assert.Len(t, joined.Tracks[0].TrackSegments, 2)
+	assert.Equal(t, "Evening", joined.Tracks[1].Name)
+	assert.Len(t, afternoon.Tracks[0].TrackSegments, 1)
+	assert.Len(t, morning.Tracks[0].TrackSegments, 1)
+}
+
+func Test_MergeDuplicateWayPoints(t *testing.T) {
+	parsed, err := gpx.ParseReader(strings.NewReader(`<gpx version="1.1">
+		<wpt lat="1" lon="2"><time>2020-01-01T10:00:00Z</time><name>Shop</name></wpt>
+		<wpt lat="3" lon="4"><time>2020-01-01T10:00:00.000Z</time><name>Cafe</name></wpt>
+	</gpx>`))
+	require.Nil(t, err)
+	other, err := gpx.ParseReader(strings.NewReader(`<gpx version="1.1">
+		<wpt lat="1" lon="2"><time>2020-01-01T10:00:00.000Z</time><name>Shop</name></wpt>
+	</gpx>`))
+	require.Nil(t, err)
+	built := &gpx.GPX{Waypoints: []gpx.WayPoint{
+		{Latitude: 3, Longitude: 4, Name: "Cafe", Timestamp: gpx.NewDateTime(time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC))},
+		{Latitude: 3, Longitude: 4, Name: "Cafe", Timestamp: gpx.NewDateTime(time.Date(2020, 1, 1, 11, 0, 0, 0, time.UTC))},
+	}}
+
+	merged := gpx.Merge([]*gpx.GPX{parsed, other, built})
+	require.Len(t, merged.Waypoints, 3)
+	assert.Equal(t, "Shop", merged.Waypoints[0].Name)
+	assert.Equal(t, "Cafe", merged.Waypoints[1].Name)
+	assert.Equal(t, 11, merged.Waypoints[2].Timestamp.Hour())
+}
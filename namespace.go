@@ -0,0 +1,251 @@
+package gpx
+
+import (
+	"slices"
+	"strings"
+
+	xml "github.com/Zauberstuhl/go-xml"
+)
+
+// Namespaces of GPX and of the extensions it can contain
+const (
+	GPXNamespace                   = "http://www.topografix.com/GPX/1/1"
+	GpxExtensionsNamespace         = "http://www.garmin.com/xmlschemas/GpxExtensions/v3"
+	TrackPointExtensionNamespace   = "http://www.garmin.com/xmlschemas/TrackPointExtension/v1"
+	TrackPointExtensionV2Namespace = "http://www.garmin.com/xmlschemas/TrackPointExtension/v2"
+	PowerExtensionNamespace        = "http://www.garmin.com/xmlschemas/PowerExtension/v1"
+
+	xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// namespace binds the prefix used by the struct tags to a namespace and its schema
+type namespace struct {
+	prefix string
+	uri    string
+	schema string
+}
+
+var (
+	gpxNamespace = namespace{"", GPXNamespace, "http://www.topografix.com/GPX/1/1/gpx.xsd"}
+
+	gpxxNamespace     = namespace{"gpxx", GpxExtensionsNamespace, "http://www.garmin.com/xmlschemas/GpxExtensionsv3.xsd"}
+	gpxtpxNamespace   = namespace{"gpxtpx", TrackPointExtensionNamespace, "http://www.garmin.com/xmlschemas/TrackPointExtensionv1.xsd"}
+	gpxtpxV2Namespace = namespace{"gpxtpx", TrackPointExtensionV2Namespace, "http://www.garmin.com/xmlschemas/TrackPointExtensionv2.xsd"}
+	pwrNamespace      = namespace{"pwr", PowerExtensionNamespace, "http://www.garmin.com/xmlschemas/PowerExtensionv1.xsd"}
+
+	// extensionNamespaces are declared on output in this order. Both versions of
+	// TrackPointExtension share a prefix as v2 extends v1, and only one of them is
+	// ever declared.
+	extensionNamespaces = []namespace{gpxxNamespace, gpxtpxNamespace, gpxtpxV2Namespace, pwrNamespace}
+)
+
+// MarshalXML writes the gpx root element, declaring the GPX namespace as well as
+// every extension namespace used in the document along with its schema location
+func (g GPX) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain GPX
+
+	if g.Version == "" {
+		g.Version = "1.1"
+	}
+
+	used := g.extensionNamespaces()
+	schemaLocation := []string{gpxNamespace.uri, gpxNamespace.schema}
+
+	start.Name = xml.Name{Local: "gpx"}
+	start.Attr = append(start.Attr,
+		xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: gpxNamespace.uri},
+		xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: xsiNamespace},
+	)
+	for _, ns := range extensionNamespaces {
+		if !used[ns.uri] {
+			continue
+		}
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + ns.prefix}, Value: ns.uri})
+		schemaLocation = append(schemaLocation, ns.uri, ns.schema)
+	}
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xsi:schemaLocation"}, Value: strings.Join(schemaLocation, " ")})
+
+	return e.EncodeElement(plain(g), start)
+}
+
+// extensionNamespaces returns the namespaces of the extensions present in the document
+func (g *GPX) extensionNamespaces() map[string]bool {
+	used := map[string]bool{}
+
+	for i := range g.Waypoints {
+		if g.Waypoints[i].Extensions.WayPointExtensions != nil {
+			used[GpxExtensionsNamespace] = true
+		}
+	}
+	for i := range g.Routes {
+		if g.Routes[i].Extensions.RouteExtensions != nil {
+			used[GpxExtensionsNamespace] = true
+		}
+		for j := range g.Routes[i].RoutePoints {
+			if g.Routes[i].RoutePoints[j].Extensions.RoutePointExtensions != nil {
+				used[GpxExtensionsNamespace] = true
+			}
+		}
+	}
+	for i := range g.Tracks {
+		if ext := g.Tracks[i].Extensions; ext != nil && ext.TrackExtensions != nil {
+			used[GpxExtensionsNamespace] = true
+		}
+		for j := range g.Tracks[i].TrackSegments {
+			for k := range g.Tracks[i].TrackSegments[j].TrackPoint {
+				ext := g.Tracks[i].TrackSegments[j].TrackPoint[k].Extensions
+				if ext == nil {
+					continue
+				}
+				if ext.TrackPointExtensions != nil {
+					used[TrackPointExtensionNamespace] = true
+					if ext.TrackPointExtensions.isV2() {
+						used[TrackPointExtensionV2Namespace] = true
+					}
+				}
+				if ext.Power != 0 {
+					used[PowerExtensionNamespace] = true
+				}
+			}
+		}
+	}
+
+	if used[TrackPointExtensionV2Namespace] {
+		delete(used, TrackPointExtensionNamespace)
+	}
+	return used
+}
+
+// namespaceReader reads raw tokens and resolves the namespace prefixes they use.
+// Elements from a known extension namespace are renamed to the prefix used by the
+// struct tags, whichever prefix the document bound that namespace to, so that
+// `ns3:hr` is read as `gpxtpx:hr` when ns3 is bound to TrackPointExtension/v1, and so
+// is `hr` inside an element declaring TrackPointExtension/v1 as its default namespace.
+// Namespace declarations are consumed and not passed on.
+type namespaceReader struct {
+	decoder *xml.Decoder
+	dialect dialect
+	scopes  []map[string]string
+}
+
+// dialect describes the names of a format read with the same decoding pipeline
+type dialect struct {
+	// namespaces are the namespaces of the format itself, whose names are read without
+	// a namespace
+	namespaces []string
+	// extensions are the known extension namespaces, renamed to the prefix of the
+	// struct tags
+	extensions []namespace
+	// points are the names of the elements counted by MaxPoints
+	points []string
+}
+
+// gpxDialect reads GPX 1.1 and 1.0 documents
+var gpxDialect = dialect{
+	namespaces: []string{GPXNamespace, GPX10Namespace},
+	extensions: extensionNamespaces,
+	points:     []string{"wpt", "rtept", "trkpt"},
+}
+
+func newNamespaceReader(decoder *xml.Decoder, d dialect) *namespaceReader {
+	return &namespaceReader{decoder: decoder, dialect: d}
+}
+
+// Token returns the next token with its names resolved
+func (r *namespaceReader) Token() (xml.Token, error) {
+	token, err := r.decoder.RawToken()
+	if err != nil {
+		return token, err
+	}
+
+	switch t := token.(type) {
+	case xml.StartElement:
+		scope := map[string]string{}
+		attrs := make([]xml.Attr, 0, len(t.Attr))
+		for _, attr := range t.Attr {
+			prefix, local := splitName(attr.Name)
+			switch {
+			case prefix == "xmlns":
+				scope[local] = attr.Value
+			case prefix == "" && local == "xmlns":
+				scope[""] = attr.Value
+			default:
+				attrs = append(attrs, attr)
+			}
+		}
+		r.scopes = append(r.scopes, scope)
+
+		for i := range attrs {
+			attrs[i].Name = r.resolve(attrs[i].Name, false)
+		}
+		return xml.StartElement{Name: r.resolve(t.Name, true), Attr: attrs}, nil
+
+	case xml.EndElement:
+		name := r.resolve(t.Name, true)
+		if len(r.scopes) > 0 {
+			r.scopes = r.scopes[:len(r.scopes)-1]
+		}
+		return xml.EndElement{Name: name}, nil
+	}
+
+	return token, nil
+}
+
+// lookup returns the namespace bound to a prefix in the current scope
+func (r *namespaceReader) lookup(prefix string) (string, bool) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if uri, ok := r.scopes[i][prefix]; ok {
+			return uri, true
+		}
+	}
+	return "", false
+}
+
+// resolve turns a raw name into the name expected by the struct tags, matching
+// extensions on their namespace rather than on the prefix they were written with.
+// Unprefixed elements are in the default namespace, while unprefixed attributes
+// have no namespace. Names from GPX have no namespace, and all the others keep it in
+// Space with the prefix they are written with in Local, so that unknown extensions
+// can be written back as they were.
+// Undeclared prefixes are kept as they are, which still matches the struct tags for
+// files that use the usual prefixes without declaring them.
+func (r *namespaceReader) resolve(name xml.Name, element bool) xml.Name {
+	prefix, local := splitName(name)
+	if prefix == "xml" || (prefix == "" && !element) {
+		return xml.Name{Space: prefix, Local: local}
+	}
+
+	uri, ok := r.lookup(prefix)
+	switch {
+	case !ok && prefix == "":
+		return xml.Name{Local: local}
+	case !ok && element:
+		return xml.Name{Local: prefix + ":" + local}
+	case !ok:
+		return xml.Name{Space: prefix, Local: local}
+	case uri == "" || slices.Contains(r.dialect.namespaces, uri):
+		return xml.Name{Local: local}
+	}
+
+	for _, ns := range r.dialect.extensions {
+		if ns.uri == uri {
+			return xml.Name{Space: uri, Local: ns.prefix + ":" + local}
+		}
+	}
+	if prefix == "" {
+		return xml.Name{Space: uri, Local: local}
+	}
+	return xml.Name{Space: uri, Local: prefix + ":" + local}
+}
+
+// splitName returns the prefix and local part of a raw name. The decoder only splits
+// some prefixed names, depending on the character following them.
+func splitName(name xml.Name) (string, string) {
+	if name.Space != "" {
+		return name.Space, name.Local
+	}
+	if i := strings.Index(name.Local, ":"); i > 0 {
+		return name.Local[:i], name.Local[i+1:]
+	}
+	return "", name.Local
+}
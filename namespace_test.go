@@ -0,0 +1,157 @@
+package gpx_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+// namespacesOf returns the namespace of every element name in an XML document,
+// as resolved by a namespace aware parser
+func namespacesOf(t *testing.T, data []byte) map[string]string {
+	namespaces := map[string]string{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return namespaces
+		}
+		require.Nil(t, err)
+		if start, ok := token.(xml.StartElement); ok {
+			namespaces[start.Name.Local] = start.Name.Space
+		}
+	}
+}
+
+func Test_EncodeNamespaces(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/strava-1427712053.gpx")
+	require.Nil(t, err)
+
+	buf := bytes.Buffer{}
+	encoder := gpx.NewEncoder(&buf)
+	require.Nil(t, encoder.Encode(g))
+
+	out := buf.String()
+	assert.Contains(t, out, `xmlns="http://www.topografix.com/GPX/1/1"`)
+	assert.Contains(t, out, `xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1"`)
+	assert.Contains(t, out, `xsi:schemaLocation="http://www.topografix.com/GPX/1/1 http://www.topografix.com/GPX/1/1/gpx.xsd http://www.garmin.com/xmlschemas/TrackPointExtension/v1 http://www.garmin.com/xmlschemas/TrackPointExtensionv1.xsd"`)
+	assert.NotContains(t, out, `xmlns:gpxx`)
+
+	namespaces := namespacesOf(t, buf.Bytes())
+	assert.Equal(t, gpx.GPXNamespace, namespaces["gpx"])
+	assert.Equal(t, gpx.GPXNamespace, namespaces["trkpt"])
+	assert.Equal(t, gpx.TrackPointExtensionNamespace, namespaces["TrackPointExtension"])
+	assert.Equal(t, gpx.TrackPointExtensionNamespace, namespaces["hr"])
+}
+
+func Test_EncodeGarminExtensionNamespaces(t *testing.T) {
+	g := gpx.GPX{
+		Creator: "test",
+		Waypoints: []gpx.WayPoint{{
+			Extensions: gpx.WayPointExtensions{WayPointExtensions: &gpx.WayPointExtension{
+				PhoneNumber: []gpx.PhoneNumber{{Category: "Phone", Number: "555"}},
+			}},
+		}},
+		Tracks: []gpx.Track{{Extensions: &gpx.TrackExtensions{TrackExtensions: &gpx.TrackExtension{DisplayColor: gpx.Red}}}},
+	}
+
+	buf := bytes.Buffer{}
+	encoder := gpx.NewEncoder(&buf)
+	require.Nil(t, encoder.Encode(&g))
+	assert.Contains(t, buf.String(), `version="1.1"`)
+	assert.Contains(t, buf.String(), `<gpxx:PhoneNumber Category="Phone">555</gpxx:PhoneNumber>`)
+
+	namespaces := namespacesOf(t, buf.Bytes())
+	assert.Equal(t, gpx.GpxExtensionsNamespace, namespaces["WaypointExtension"])
+	assert.Equal(t, gpx.GpxExtensionsNamespace, namespaces["TrackExtension"])
+	assert.Equal(t, gpx.GpxExtensionsNamespace, namespaces["DisplayColor"])
+
+	p := gpx.GPX{}
+	require.Nil(t, gpx.Parse(buf.Bytes(), &p))
+	assert.Equal(t, gpx.Red, p.Tracks[0].Extensions.TrackExtensions.DisplayColor)
+	assert.Equal(t, "555", p.Waypoints[0].Extensions.WayPointExtensions.PhoneNumber[0].Number)
+}
+
+func Test_DecodeNormalisesPrefixes(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<g:gpx xmlns:g="http://www.topografix.com/GPX/1/1" xmlns:ns3="http://www.garmin.com/xmlschemas/TrackPointExtension/v1" version="1.1" creator="test">
+  <g:trk>
+    <g:name>prefixed</g:name>
+    <g:trkseg>
+      <g:trkpt lat="1.5" lon="2.5">
+        <g:extensions>
+          <ns3:TrackPointExtension>
+            <ns3:hr>120</ns3:hr>
+            <ns3:cad>80</ns3:cad>
+          </ns3:TrackPointExtension>
+        </g:extensions>
+      </g:trkpt>
+    </g:trkseg>
+  </g:trk>
+</g:gpx>`)
+
+	g := gpx.GPX{}
+	require.Nil(t, gpx.Parse(data, &g))
+	assert.Equal(t, "prefixed", g.Tracks[0].Name)
+
+	point := g.Tracks[0].TrackSegments[0].TrackPoint[0]
+	assert.Equal(t, gpx.Latitude(1.5), point.Latitude)
+	assert.Equal(t, gpx.BeatsPerMinute(120), point.Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.RevolutionsPerMinute(80), point.Extensions.TrackPointExtensions.Cadence)
+
+	buf := bytes.Buffer{}
+	encoder := gpx.NewEncoder(&buf)
+	require.Nil(t, encoder.Encode(&g))
+	assert.Contains(t, buf.String(), "<gpxtpx:hr>120</gpxtpx:hr>")
+	assert.NotContains(t, buf.String(), "ns3")
+}
+
+func Test_DecodeExtensionsWithAnyPrefix(t *testing.T) {
+	files := []string{
+		"extensions-canonical",
+		"extensions-ns3",
+		"extensions-default-namespace",
+		"extensions-local-prefix",
+		"extensions-undeclared",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			g, err := gpx.ParseFile("./samples/" + file + ".gpx")
+			require.Nil(t, err)
+
+			wpt := g.Waypoints[0].Extensions.WayPointExtensions
+			require.NotNil(t, wpt)
+			assert.Equal(t, gpx.Metres(15.24), wpt.Proximity)
+			assert.Equal(t, gpx.SymbolAndName, wpt.DisplayMode)
+
+			rte := g.Routes[0].Extensions.RouteExtensions
+			require.NotNil(t, rte)
+			assert.True(t, rte.IsAutoNamed)
+			assert.Equal(t, gpx.Blue, rte.DisplayColor)
+
+			rtept := g.Routes[0].RoutePoints[0].Extensions.RoutePointExtensions
+			require.NotNil(t, rtept)
+			assert.Equal(t, gpx.SubClass("000000000000FFFFFFFFFFFFFFFFFFFFFFFF"), rtept.Subclass)
+			require.Len(t, rtept.AutoRoutePoint, 1)
+			assert.Equal(t, gpx.Latitude(38.634), rtept.AutoRoutePoint[0].Latitude)
+
+			trk := g.Tracks[0].Extensions.TrackExtensions
+			require.NotNil(t, trk)
+			assert.Equal(t, gpx.Red, trk.DisplayColor)
+
+			trkpt := g.Tracks[0].TrackSegments[0].TrackPoint[0]
+			assert.Equal(t, 150.2, *trkpt.Elevation)
+			tpx := trkpt.Extensions.TrackPointExtensions
+			require.NotNil(t, tpx)
+			assert.Equal(t, gpx.DegreesCelcius(21.5), tpx.Temperature)
+			assert.Equal(t, gpx.BeatsPerMinute(150), tpx.HeartRate)
+			assert.Equal(t, gpx.RevolutionsPerMinute(90), tpx.Cadence)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package gpx
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// ReadOption changes how a document is read
+type ReadOption func(*readOptions)
+
+// WriteOption changes how a document is written
+type WriteOption func(*writeOptions)
+
+type readOptions struct {
+	strict      bool
+	ctx         context.Context
+	maxBytes    int64
+	maxElements int
+	maxPoints   int
+	maxDepth    int
+}
+
+type writeOptions struct {
+	refreshMetadata bool
+	version10       bool
+	header          bool
+	prefix          string
+	indent          string
+	fileMode        os.FileMode
+	atomic          bool
+	creator         string
+	coordinates     bool
+	now             func() time.Time
+}
+
+// writeDefaults are applied before the options given to Write and WriteTo
+var writeDefaults = []WriteOption{Header(true), Indent("", "    ")}
+
+func newReadOptions(opts []ReadOption) readOptions {
+	o := readOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func newWriteOptions(opts []WriteOption) writeOptions {
+	o := writeOptions{fileMode: 0644, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// RefreshMetadata recomputes Metadata.Bounds from the points in the file and sets
+// Metadata.Timestamp to the current time before writing
+func RefreshMetadata() WriteOption {
+	return func(o *writeOptions) {
+		o.refreshMetadata = true
+	}
+}
+
+// Clock sets the function giving the current time, which RefreshMetadata and courses
+// without timestamps use, time.Now by default
+func Clock(now func() time.Time) WriteOption {
+	return func(o *writeOptions) {
+		o.now = now
+	}
+}
+
+// Strict makes decoding fail with a *ValidationError when the document doesn't follow
+// the GPX 1.1 schema or the schemas of the Garmin extensions, see Validate
+func Strict() ReadOption {
+	return func(o *readOptions) {
+		o.strict = true
+	}
+}
+
+// GPX10 writes the document as GPX 1.0 for consumers which don't read GPX 1.1.
+// Metadata moves back to the root element, only the first link of each element is
+// kept, and extensions are dropped except for the speed and course of track points.
+func GPX10() WriteOption {
+	return func(o *writeOptions) {
+		o.version10 = true
+	}
+}
+
+// document returns the value which should be marshalled for g
+func (o *writeOptions) document(g *GPX) any {
+	g = o.prepare(g)
+	if o.version10 {
+		return toGPX10(g)
+	}
+	return g
+}
+
+// Indent writes each element on a new line starting with prefix and indented with one
+// or more copies of indent. Write and WriteTo indent with four spaces by default.
+func Indent(prefix, indent string) WriteOption {
+	return func(o *writeOptions) {
+		o.prefix = prefix
+		o.indent = indent
+	}
+}
+
+// Header tells whether the XML declaration is written before the document. Write and
+// WriteTo write it by default, while Encoder doesn't.
+func Header(include bool) WriteOption {
+	return func(o *writeOptions) {
+		o.header = include
+	}
+}
+
+// FileMode sets the permissions of files created by Write, 0644 by default
+func FileMode(mode os.FileMode) WriteOption {
+	return func(o *writeOptions) {
+		o.fileMode = mode
+	}
+}
+
+// Atomic makes Write write to a temporary file in the same directory and rename it
+// once complete, so that readers never see a partially written file
+func Atomic() WriteOption {
+	return func(o *writeOptions) {
+		o.atomic = true
+	}
+}
+
+// Creator sets the creator attribute of the written document
+func Creator(name string) WriteOption {
+	return func(o *writeOptions) {
+		o.creator = name
+	}
+}
+
+// prepare returns the document which should be written, leaving g untouched
+func (o *writeOptions) prepare(g *GPX) *GPX {
+	if !o.refreshMetadata && o.creator == "" {
+		return g
+	}
+
+	out := *g
+	if o.refreshMetadata {
+		out.Metadata.Bounds = g.ComputeBounds()
+		out.Metadata.Timestamp = NewDateTime(o.now().UTC().Truncate(time.Second))
+	}
+	if o.creator != "" {
+		out.Creator = o.creator
+	}
+	return &out
+}
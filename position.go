@@ -0,0 +1,193 @@
+package gpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	xml "github.com/Zauberstuhl/go-xml"
+)
+
+// positionReader counts lines in the bytes it reads, so that offsets reported by the
+// decoder can be turned into a line and column. It fails with a LimitError once more
+// than limit bytes are read, when limit is set.
+type positionReader struct {
+	reader    io.Reader
+	read      int64
+	limit     int64
+	newlines  []int64
+	line      int
+	lineStart int64
+}
+
+func newPositionReader(r io.Reader) *positionReader {
+	return &positionReader{reader: r}
+}
+
+// Read reads from the underlying reader, wrapping its errors in IOError
+func (p *positionReader) Read(b []byte) (int, error) {
+	if p.limit > 0 && int64(len(b)) > p.limit-p.read+1 {
+		b = b[:p.limit-p.read+1]
+	}
+
+	n, err := p.reader.Read(b)
+	if err != nil && err != io.EOF {
+		err = &IOError{Err: err}
+	}
+	if p.limit > 0 && p.read+int64(n) > p.limit {
+		n = int(p.limit - p.read)
+		err = &LimitError{Limit: "bytes", Max: p.limit}
+	}
+	for i := 0; i < n; i++ {
+		if b[i] == '\n' {
+			p.newlines = append(p.newlines, p.read+int64(i))
+		}
+	}
+	p.read += int64(n)
+	return n, err
+}
+
+// position returns the line and column, both starting at 1, of a byte offset. Offsets
+// have to be asked for in increasing order, as newlines before them are forgotten.
+func (p *positionReader) position(offset int64) (int, int) {
+	i := 0
+	for i < len(p.newlines) && p.newlines[i] < offset {
+		p.lineStart = p.newlines[i] + 1
+		p.line++
+		i++
+	}
+	p.newlines = p.newlines[i:]
+	return p.line + 1, int(offset-p.lineStart) + 1
+}
+
+// tracker follows where the decoder is in the document, so that errors and issues can
+// point at the element they come from
+type tracker struct {
+	tokens   xml.TokenReader
+	decoder  *xml.Decoder
+	position *positionReader
+
+	// elements are the elements which have been started and not ended yet, and last
+	// is the element which ended most recently
+	elements []trackedElement
+	last     trackedElement
+	// started is set when the last token read was a start element
+	started bool
+}
+
+// trackedElement is an element with its position and the text read directly inside it
+// since its last child
+type trackedElement struct {
+	name   string
+	attrs  []xml.Attr
+	line   int
+	column int
+	text   []byte
+}
+
+func newTracker(r io.Reader, d dialect) *tracker {
+	position := newPositionReader(r)
+	decoder := xml.NewDecoder(position)
+	return &tracker{
+		tokens:   newNamespaceReader(decoder, d),
+		decoder:  decoder,
+		position: position,
+	}
+}
+
+// Token returns the next token, keeping track of the element it belongs to
+func (t *tracker) Token() (xml.Token, error) {
+	offset := t.decoder.InputOffset()
+	token, err := t.tokens.Token()
+	if err != nil {
+		return token, err
+	}
+
+	t.started = false
+	switch tok := token.(type) {
+	case xml.StartElement:
+		if n := len(t.elements); n > 0 {
+			t.elements[n-1].text = t.elements[n-1].text[:0]
+		}
+		element := trackedElement{name: tok.Name.Local, attrs: tok.Attr}
+		element.line, element.column = t.position.position(offset)
+		t.elements = append(t.elements, element)
+		t.started = true
+	case xml.CharData:
+		if n := len(t.elements); n > 0 {
+			t.elements[n-1].text = append(t.elements[n-1].text, tok...)
+		}
+	case xml.EndElement:
+		if n := len(t.elements); n > 0 {
+			t.last = t.elements[n-1]
+			t.elements = t.elements[:n-1]
+		}
+	}
+	return token, nil
+}
+
+// current returns the element the decoder is working on: the one just started, or
+// the one just ended since values are only converted once their text has been read
+func (t *tracker) current() (trackedElement, string) {
+	names := make([]string, 0, len(t.elements)+1)
+	for _, element := range t.elements {
+		names = append(names, element.name)
+	}
+	if t.started && len(t.elements) > 0 {
+		return t.elements[len(t.elements)-1], "/" + strings.Join(names, "/")
+	}
+	names = append(names, t.last.name)
+	return t.last, "/" + strings.Join(names, "/")
+}
+
+// wrap turns an error returned while decoding into one of the errors of this package
+func (t *tracker) wrap(err error) error {
+	if err == nil || err == io.EOF || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var (
+		ioErr       *IOError
+		limit       *LimitError
+		version     *UnsupportedVersionError
+		validation  *ValidationError
+		unmarshal   xml.UnmarshalError
+		syntax      *xml.SyntaxError
+		numberError *strconv.NumError
+	)
+	switch {
+	case errors.As(err, &ioErr), errors.As(err, &limit), errors.As(err, &version), errors.As(err, &validation), errors.As(err, &unmarshal):
+		return err
+	case errors.As(err, &syntax), err == io.ErrUnexpectedEOF:
+		message := "unexpected EOF"
+		if syntax != nil {
+			message = syntax.Msg
+		}
+		offset := t.decoder.InputOffset()
+		line, column := t.position.position(offset)
+		return &SyntaxError{Offset: offset, Line: line, Column: column, Message: message, Err: err}
+	}
+
+	element, path := t.current()
+	valueErr := &ValueError{Path: path, Line: element.line, Column: element.column, Err: err}
+	if !t.started {
+		valueErr.Value = strings.TrimSpace(string(element.text))
+		return valueErr
+	}
+
+	for _, attr := range element.attrs {
+		if errors.As(err, &numberError) && numberError.Num == strings.TrimSpace(attr.Value) {
+			valueErr.Path += "/@" + attr.Name.Local
+			valueErr.Attr = attr.Name.Local
+			valueErr.Value = attr.Value
+			break
+		}
+	}
+	switch valueErr.Attr {
+	case "lat", "lon", "minlat", "minlon", "maxlat", "maxlon":
+		return (*CoordinateError)(valueErr)
+	}
+	return valueErr
+}
@@ -0,0 +1,127 @@
+package gpx
+
+import (
+	"strings"
+
+	xml "github.com/Zauberstuhl/go-xml"
+)
+
+// xmlNamespace is the namespace bound to the reserved xml prefix
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// RawElement is an element inside extensions which this package doesn't model, kept
+// as it was read so that it is written back unchanged.
+// Name.Space holds the namespace of the element and Name.Local the name it was written
+// with, including its prefix. Namespaces are declared again when writing, on the
+// outermost element which uses them.
+// Whitespace between child elements and comments are not kept.
+type RawElement struct {
+	Name     xml.Name
+	Attr     []xml.Attr
+	Text     string
+	Children []RawElement
+}
+
+// UnmarshalXML reads the element and everything it contains
+func (r *RawElement) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	r.Name = start.Name
+	r.Attr = append([]xml.Attr(nil), start.Attr...)
+
+	text := strings.Builder{}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child := RawElement{}
+			if err := child.UnmarshalXML(d, t); err != nil {
+				return err
+			}
+			r.Children = append(r.Children, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			r.Text = text.String()
+			if len(r.Children) > 0 && strings.TrimSpace(r.Text) == "" {
+				r.Text = ""
+			}
+			return nil
+		}
+	}
+}
+
+// MarshalXML writes the element back with the name it was read with
+func (r RawElement) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return r.encode(e, map[string]string{})
+}
+
+// encode writes the element, declaring the namespaces it uses which aren't already
+// bound in scope. The scope maps prefixes, and "" for the default namespace, to
+// the namespace they are bound to by enclosing raw elements.
+func (r *RawElement) encode(e *xml.Encoder, scope map[string]string) error {
+	start := xml.StartElement{Name: xml.Name{Local: r.Name.Local}}
+
+	inner := scope
+	copied := false
+	bind := func(prefix, uri string) {
+		if bound, ok := inner[prefix]; ok && bound == uri {
+			return
+		}
+		if prefix == "" && uri == "" && inner[""] == "" {
+			return
+		}
+		if !copied {
+			inner = make(map[string]string, len(scope)+1)
+			for k, v := range scope {
+				inner[k] = v
+			}
+			copied = true
+		}
+		inner[prefix] = uri
+
+		name := "xmlns"
+		if prefix != "" {
+			name += ":" + prefix
+		}
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: uri})
+	}
+
+	prefix, _ := splitName(xml.Name{Local: r.Name.Local})
+	if r.Name.Space != "" || prefix == "" {
+		bind(prefix, r.Name.Space)
+	}
+
+	for _, attr := range r.Attr {
+		name := attr.Name
+		switch {
+		case name.Space == "":
+		case name.Space == xmlNamespace:
+			name.Local = "xml:" + name.Local
+		case strings.Contains(name.Local, ":"):
+			attrPrefix, _ := splitName(xml.Name{Local: name.Local})
+			bind(attrPrefix, name.Space)
+		default:
+			// undeclared prefix, kept as it was
+			name.Local = name.Space + ":" + name.Local
+		}
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: name.Local}, Value: attr.Value})
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if r.Text != "" {
+		if err := e.EncodeToken(xml.CharData(r.Text)); err != nil {
+			return err
+		}
+	}
+	for i := range r.Children {
+		if err := r.Children[i].encode(e, inner); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
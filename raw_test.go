@@ -0,0 +1,78 @@
+package gpx_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_UnknownExtensionsParser(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/unknown-extensions.gpx")
+	require.Nil(t, err)
+
+	require.Len(t, g.Metadata.Extensions.Unknown, 1)
+	assert.Equal(t, "osmand:desc", g.Metadata.Extensions.Unknown[0].Name.Local)
+	assert.Equal(t, "https://osmand.net", g.Metadata.Extensions.Unknown[0].Name.Space)
+	assert.Equal(t, "Exported from OsmAnd", g.Metadata.Extensions.Unknown[0].Text)
+
+	wpt := g.Waypoints[0].Extensions
+	assert.Equal(t, gpx.Metres(25), wpt.WayPointExtensions.Proximity)
+	require.Len(t, wpt.Unknown, 2)
+	assert.Equal(t, "#eecc22", wpt.Unknown[1].Text)
+
+	assert.Equal(t, "5", g.Routes[0].Extensions.Unknown[0].Text)
+	assert.Equal(t, "3", g.Routes[0].RoutePoints[0].Extensions.Unknown[0].Text)
+
+	trk := g.Tracks[0].Extensions
+	assert.Equal(t, gpx.Red, trk.TrackExtensions.DisplayColor)
+	assert.Equal(t, "gpxx:Vendor", trk.TrackExtensions.Extensions.Unknown[0].Name.Local)
+	assert.Equal(t, "cycling", trk.Unknown[0].Text)
+
+	trkpt := g.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions
+	assert.Equal(t, gpx.BeatsPerMinute(140), trkpt.TrackPointExtensions.HeartRate)
+	assert.Equal(t, "22", trkpt.TrackPointExtensions.Extensions.Unknown[0].Text)
+	require.Len(t, trkpt.Unknown, 3)
+	vendor := trkpt.Unknown[2]
+	assert.Equal(t, xml.Name{Space: "urn:example:vendor", Local: "vendor"}, xml.Name(vendor.Name))
+	require.Len(t, vendor.Children, 2)
+	assert.Equal(t, "urn:example:vendor", vendor.Children[1].Name.Space)
+	assert.Equal(t, "4", vendor.Children[1].Text)
+	assert.Equal(t, "s", vendor.Children[1].Attr[0].Value)
+
+	assert.Equal(t, "1", g.Tracks[0].TrackSegments[0].Extensions.Unknown[0].Text)
+}
+
+func Test_UnknownExtensionsRoundTrip(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/unknown-extensions.gpx")
+	require.Nil(t, err)
+
+	first := bytes.Buffer{}
+	encoder := gpx.NewEncoder(&first)
+	require.Nil(t, encoder.Encode(g))
+
+	out := first.String()
+	assert.Contains(t, out, `<osmand:icon xmlns:osmand="https://osmand.net">special_star</osmand:icon>`)
+	assert.Contains(t, out, `<gpxdata:sensor xmlns:gpxdata="http://www.cluetrust.com/XML/GPXDATA/1/0" type="heart" gpxdata:source="chest strap" xml:lang="en">strap</gpxdata:sensor>`)
+	assert.Contains(t, out, `<vendor xmlns="urn:example:vendor" version="2"><reading unit="m">3</reading><reading unit="s">4</reading></vendor>`)
+
+	namespaces := namespacesOf(t, first.Bytes())
+	assert.Equal(t, "https://osmand.net", namespaces["segment_id"])
+	assert.Equal(t, "https://www.locusmap.app", namespaces["activity"])
+	assert.Equal(t, "urn:example:vendor", namespaces["reading"])
+	assert.Equal(t, gpx.TrackPointExtensionNamespace, namespaces["respiration"])
+	assert.Equal(t, gpx.GpxExtensionsNamespace, namespaces["Vendor"])
+
+	p := gpx.GPX{}
+	require.Nil(t, gpx.Parse(first.Bytes(), &p))
+	assert.Equal(t, g.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.Unknown, p.Tracks[0].TrackSegments[0].TrackPoint[0].Extensions.Unknown)
+	assert.Equal(t, g.Waypoints[0].Extensions.Unknown, p.Waypoints[0].Extensions.Unknown)
+
+	second := bytes.Buffer{}
+	encoder = gpx.NewEncoder(&second)
+	require.Nil(t, encoder.Encode(&p))
+	assert.Equal(t, first.String(), second.String())
+}
@@ -0,0 +1,198 @@
+package gpx
+
+import (
+	"math"
+	"time"
+)
+
+// SensorMode chooses how heart rate, cadence, temperature, speed and power are filled
+// in between two track points when resampling
+type SensorMode int
+
+const (
+	// InterpolateSensors interpolates sensor values linearly between the points around
+	// a sample, and carries the value of the point before it forward when the point
+	// after it has none
+	InterpolateSensors SensorMode = iota + 1
+	// CarryForwardSensors gives every sample the sensor values of the point before it
+	CarryForwardSensors
+)
+
+// MaxResampledPoints is the largest number of points a resampled segment has, three
+// days at a point every second. Intervals which would give more points are widened to
+// give this many.
+const MaxResampledPoints = 1 << 18
+
+// ResampleByTime returns a segment with a point every interval, starting at the first
+// point with a timestamp and ending at or before the last one. Points without a
+// timestamp, or with one not after the point before them, are ignored. At most
+// MaxResampledPoints points are returned.
+// Positions are interpolated along great circles, elevations and timestamps linearly,
+// and sensor values according to the mode. The segment is copied unchanged when the
+// interval isn't positive.
+func (s *TrackSegment) ResampleByTime(interval time.Duration, mode SensorMode) TrackSegment {
+	out := *s
+	if interval <= 0 {
+		return out
+	}
+
+	points := []TrackPoint{}
+	keys := []float64{}
+	for _, point := range s.TrackPoint {
+		if point.Timestamp.IsZero() {
+			continue
+		}
+		if len(points) > 0 && !point.Timestamp.After(points[len(points)-1].Timestamp.Time) {
+			continue
+		}
+		points = append(points, point)
+		keys = append(keys, float64(point.Timestamp.Sub(points[0].Timestamp.Time)))
+	}
+
+	if len(keys) > 0 {
+		interval = time.Duration(math.Ceil(sampleInterval(keys[len(keys)-1], float64(interval))))
+	}
+	out.TrackPoint = resample(points, keys, float64(interval), mode)
+	for i := range out.TrackPoint {
+		// Timestamps are set exactly, instead of being interpolated, to avoid rounding
+		out.TrackPoint[i].Timestamp = NewDateTime(points[0].Timestamp.Add(time.Duration(i) * interval))
+	}
+	return out
+}
+
+// ResampleByDistance returns a segment with a point every interval of distance along
+// the segment, starting at the first point and ending at or before the last one.
+// Positions are interpolated along great circles, elevations and timestamps linearly,
+// and sensor values according to the mode. Timestamps are only set between points
+// which both have one. At most MaxResampledPoints points are returned. The segment is
+// copied unchanged when the interval isn't positive.
+func (s *TrackSegment) ResampleByDistance(interval Metres, mode SensorMode) TrackSegment {
+	out := *s
+	if interval <= 0 {
+		return out
+	}
+
+	points := []TrackPoint{}
+	keys := []float64{}
+	distance := Metres(0)
+	for _, point := range s.TrackPoint {
+		if len(points) > 0 {
+			step := Distance(points[len(points)-1], point)
+			if step == 0 {
+				// Keep the last point of a stop, which has the time moving starts again
+				points[len(points)-1] = point
+				continue
+			}
+			distance += step
+		}
+		points = append(points, point)
+		keys = append(keys, float64(distance))
+	}
+
+	out.TrackPoint = resample(points, keys, sampleInterval(float64(distance), float64(interval)), mode)
+	return out
+}
+
+// sampleInterval returns the interval at which keys spanning span are sampled, which is
+// widened to give at most MaxResampledPoints samples
+func sampleInterval(span, interval float64) float64 {
+	return max(interval, span/(MaxResampledPoints-1))
+}
+
+// resample samples the points every interval of their keys, which have to be
+// increasing, starting at the first key
+func resample(points []TrackPoint, keys []float64, interval float64, mode SensorMode) []TrackPoint {
+	if len(points) == 0 {
+		return nil
+	}
+
+	last := keys[len(keys)-1]
+	samples := make([]TrackPoint, 0, int(last/interval)+1)
+	j := 0
+	for n := 0; ; n++ {
+		key := keys[0] + float64(n)*interval
+		if key > last {
+			break
+		}
+		for j < len(points)-1 && keys[j+1] <= key {
+			j++
+		}
+		if j == len(points)-1 {
+			samples = append(samples, interpolate(points[j], points[j], 0, mode))
+			continue
+		}
+		fraction := (key - keys[j]) / (keys[j+1] - keys[j])
+		samples = append(samples, interpolate(points[j], points[j+1], fraction, mode))
+	}
+	return samples
+}
+
+// interpolate returns the point at a fraction of the way from a to b
+func interpolate(a, b TrackPoint, fraction float64, mode SensorMode) TrackPoint {
+	position := Intermediate(a, b, fraction)
+	point := TrackPoint{
+		Latitude:  position.Latitude,
+		Longitude: position.Longitude,
+		Elevation: a.Elevation,
+	}
+	if a.Elevation != nil && b.Elevation != nil {
+		elevation := interpolateValue(*a.Elevation, *b.Elevation, fraction)
+		point.Elevation = &elevation
+	}
+	if !a.Timestamp.IsZero() && !b.Timestamp.IsZero() {
+		elapsed := float64(b.Timestamp.Sub(a.Timestamp.Time))
+		point.Timestamp = NewDateTime(a.Timestamp.Add(time.Duration(math.Round(elapsed * fraction))))
+	}
+
+	point.Extensions = interpolateExtensions(a.Extensions, b.Extensions, fraction, mode)
+	return point
+}
+
+// interpolateExtensions returns the extensions of the point before a sample, with the
+// heart rate, cadence, temperature, speed and power interpolated towards those of the
+// point after it according to the mode. Heart rates and cadences of zero are taken as
+// no value, while temperatures are interpolated whenever both points have a
+// TrackPointExtension, speeds whenever both have one and power whenever both have
+// extensions, so that readings of zero count. Course, bearing, the other values and
+// unknown extensions are carried forward.
+func interpolateExtensions(a, b *TrackPointExtensions, fraction float64, mode SensorMode) *TrackPointExtensions {
+	if a == nil {
+		return nil
+	}
+	out := *a
+	out.Unknown = append([]RawElement(nil), a.Unknown...)
+	if a.TrackPointExtensions != nil {
+		extension := *a.TrackPointExtensions
+		out.TrackPointExtensions = &extension
+	}
+	if mode == CarryForwardSensors || b == nil {
+		return &out
+	}
+
+	out.Power = Watts(math.Round(interpolateValue(float64(a.Power), float64(b.Power), fraction)))
+	before, after := out.TrackPointExtensions, b.TrackPointExtensions
+	if before == nil || after == nil {
+		return &out
+	}
+	before.HeartRate = BeatsPerMinute(math.Round(interpolateSensor(float64(before.HeartRate), float64(after.HeartRate), fraction)))
+	before.Cadence = RevolutionsPerMinute(math.Round(interpolateSensor(float64(before.Cadence), float64(after.Cadence), fraction)))
+	before.Temperature = DegreesCelcius(interpolateValue(float64(before.Temperature), float64(after.Temperature), fraction))
+	if before.Speed != nil && after.Speed != nil {
+		speed := MetresPerSecond(interpolateValue(float64(*before.Speed), float64(*after.Speed), fraction))
+		before.Speed = &speed
+	}
+	return &out
+}
+
+// interpolateSensor interpolates a sensor value, where zero means there is no value
+func interpolateSensor(before, after, fraction float64) float64 {
+	if before == 0 || after == 0 {
+		return before
+	}
+	return interpolateValue(before, after, fraction)
+}
+
+// interpolateValue interpolates a value linearly
+func interpolateValue(before, after, fraction float64) float64 {
+	return before + (after-before)*fraction
+}
@@ -0,0 +1,133 @@
+package gpx_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	xml "github.com/Zauberstuhl/go-xml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func withSensors(p gpx.TrackPoint, hr gpx.BeatsPerMinute, cad gpx.RevolutionsPerMinute, temp gpx.DegreesCelcius) gpx.TrackPoint {
+	p.Extensions = &gpx.TrackPointExtensions{TrackPointExtensions: &gpx.TrackPointExtension{
+		HeartRate:   hr,
+		Cadence:     cad,
+		Temperature: temp,
+	}}
+	return p
+}
+
+func Test_ResampleByTime(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	segment := gpx.TrackSegment{TrackPoint: []gpx.TrackPoint{
+		withSensors(trackPoint(0, 0, 10, start), 100, 80, 20),
+		{Latitude: 5, Longitude: 5},
+		withSensors(trackPoint(0, 0.004, 30, start.Add(4*time.Second)), 120, 0, 22),
+		withSensors(trackPoint(0, 0.005, 30, start.Add(5500*time.Millisecond)), 130, 90, 23),
+	}}
+
+	resampled := segment.ResampleByTime(time.Second, gpx.InterpolateSensors)
+	points := resampled.TrackPoint
+	require.Len(t, points, 6)
+	for i, point := range points {
+		assert.Equal(t, start.Add(time.Duration(i)*time.Second), point.Timestamp.Time)
+	}
+	assert.InDelta(t, 0.001, float64(points[1].Longitude), 1e-9)
+	assert.InDelta(t, 0, float64(points[1].Latitude), 1e-9)
+	assert.InDelta(t, 15, *points[1].Elevation, 1e-9)
+	assert.Equal(t, gpx.BeatsPerMinute(105), points[1].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.RevolutionsPerMinute(80), points[1].Extensions.TrackPointExtensions.Cadence)
+	assert.InDelta(t, 20.5, float64(points[1].Extensions.TrackPointExtensions.Temperature), 1e-9)
+
+	// The fifth sample is exactly on the third point
+	assert.Equal(t, gpx.BeatsPerMinute(120), points[4].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.RevolutionsPerMinute(0), points[4].Extensions.TrackPointExtensions.Cadence)
+	assert.InDelta(t, 0.004667, float64(points[5].Longitude), 1e-6)
+
+	carried := segment.ResampleByTime(time.Second, gpx.CarryForwardSensors)
+	assert.Equal(t, gpx.BeatsPerMinute(100), carried.TrackPoint[3].Extensions.TrackPointExtensions.HeartRate)
+	assert.InDelta(t, 20, float64(carried.TrackPoint[3].Extensions.TrackPointExtensions.Temperature), 1e-9)
+
+	assert.Len(t, segment.TrackPoint, 4)
+	assert.Equal(t, segment, segment.ResampleByTime(0, gpx.InterpolateSensors))
+
+	// Tiny intervals are widened
+	long := gpx.TrackSegment{TrackPoint: []gpx.TrackPoint{trackPoint(0, 0, 0, start), trackPoint(0, 1, 0, start.Add(time.Hour))}}
+	points = long.ResampleByTime(time.Nanosecond, gpx.InterpolateSensors).TrackPoint
+	assert.LessOrEqual(t, len(points), gpx.MaxResampledPoints)
+	assert.Greater(t, len(points), gpx.MaxResampledPoints-10)
+	assert.LessOrEqual(t, len(long.ResampleByDistance(1e-9, gpx.InterpolateSensors).TrackPoint), gpx.MaxResampledPoints)
+}
+
+func Test_ResampleKeepsExtensions(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	first := withSensors(trackPoint(0, 0, 0, start), 100, 0, 0)
+	first.Extensions.Power = 200
+	fast, slow := gpx.MetresPerSecond(8), gpx.MetresPerSecond(6)
+	first.Extensions.TrackPointExtensions.Speed = &fast
+	course := gpx.Degrees(90)
+	first.Extensions.TrackPointExtensions.Course = &course
+	first.Extensions.Unknown = []gpx.RawElement{{Name: xml.Name{Space: "urn:example", Local: "gear"}, Text: "3"}}
+	second := withSensors(trackPoint(0, 0.001, 0, start.Add(2*time.Second)), 110, 0, 4)
+	second.Extensions.Power = 300
+	second.Extensions.TrackPointExtensions.Speed = &slow
+	segment := gpx.TrackSegment{TrackPoint: []gpx.TrackPoint{first, second}}
+
+	points := segment.ResampleByTime(time.Second, gpx.InterpolateSensors).TrackPoint
+	require.Len(t, points, 3)
+	middle := points[1].Extensions
+	assert.Equal(t, gpx.Watts(250), middle.Power)
+	assert.Equal(t, gpx.MetresPerSecond(7), *middle.TrackPointExtensions.Speed)
+	assert.Equal(t, &course, middle.TrackPointExtensions.Course)
+	// A temperature of 0 °C is a reading
+	assert.InDelta(t, 2, float64(middle.TrackPointExtensions.Temperature), 1e-9)
+	assert.Equal(t, first.Extensions.Unknown, middle.Unknown)
+
+	carried := segment.ResampleByTime(time.Second, gpx.CarryForwardSensors).TrackPoint
+	assert.Equal(t, gpx.Watts(200), carried[1].Extensions.Power)
+	assert.Equal(t, gpx.MetresPerSecond(8), *carried[1].Extensions.TrackPointExtensions.Speed)
+	assert.Equal(t, gpx.Watts(200), first.Extensions.Power)
+}
+
+func Test_ResampleByDistance(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	segment := gpx.TrackSegment{TrackPoint: []gpx.TrackPoint{
+		trackPoint(0, 0, 0, start),
+		trackPoint(0, 0, 0, start.Add(time.Minute)),
+		trackPoint(0, 0.001, 0, start.Add(70*time.Second)),
+		trackPoint(0.001, 0.001, 0, start.Add(80*time.Second)),
+	}}
+
+	resampled := segment.ResampleByDistance(10, gpx.InterpolateSensors)
+	points := resampled.TrackPoint
+	require.Len(t, points, 23)
+	for i := 1; i < len(points); i++ {
+		if i == 12 {
+			// The corner is between these two samples
+			continue
+		}
+		assert.InDelta(t, 10, float64(gpx.Distance(points[i-1], points[i])), 0.01)
+	}
+	assert.Nil(t, points[1].Extensions)
+	assert.InDelta(t, 60.899, points[1].Timestamp.Sub(start).Seconds(), 0.001)
+	assert.InDelta(t, 0.000989, float64(points[11].Longitude), 1e-6)
+	assert.InDelta(t, 0.001, float64(points[12].Longitude), 1e-9)
+	assert.InDelta(t, 0.0000792, float64(points[12].Latitude), 1e-6)
+}
+
+func Test_Intermediate(t *testing.T) {
+	from := gpx.Point{Latitude: 51.5, Longitude: -0.1}
+	to := gpx.Point{Latitude: 48.85, Longitude: 2.35}
+
+	assert.InDelta(t, 51.5, float64(gpx.Intermediate(from, to, 0).Latitude), 1e-9)
+	assert.InDelta(t, 2.35, float64(gpx.Intermediate(from, to, 1).Longitude), 1e-9)
+
+	middle := gpx.Intermediate(from, to, 0.5)
+	assert.InDelta(t, float64(gpx.Distance(from, middle)), float64(gpx.Distance(middle, to)), 1e-6)
+
+	across := gpx.Intermediate(gpx.Point{Longitude: 179}, gpx.Point{Longitude: -179}, 0.5)
+	assert.InDelta(t, 180, math.Abs(float64(across.Longitude)), 1e-9)
+}
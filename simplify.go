@@ -0,0 +1,328 @@
+package gpx
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// SimplifyAlgorithm chooses how points are removed when simplifying
+type SimplifyAlgorithm int
+
+const (
+	// DouglasPeucker (Ramer-Douglas-Peucker) keeps the points which are furthest from
+	// the line joining the points kept around them. The tolerance is the largest
+	// distance from that line of a removed point.
+	DouglasPeucker SimplifyAlgorithm = iota + 1
+	// VisvalingamWhyatt removes the points which form the smallest triangles with
+	// their neighbours first. Points are removed while their triangle has an area of
+	// at most the square of the tolerance.
+	VisvalingamWhyatt
+)
+
+// Simplify returns the segment without the points which can be removed within the
+// tolerance. The first and last points are always kept, and kept points are copied
+// unchanged, along with their timestamps and extensions.
+func (s *TrackSegment) Simplify(algorithm SimplifyAlgorithm, tolerance Metres) TrackSegment {
+	out := *s
+	out.TrackPoint = keepPoints(s.TrackPoint, significance(algorithm, s.TrackPoint).above(tolerance))
+	return out
+}
+
+// SimplifyTo returns the segment with at most the given number of points, keeping
+// the most significant ones. The first and last points are always kept.
+func (s *TrackSegment) SimplifyTo(algorithm SimplifyAlgorithm, points int) TrackSegment {
+	out := *s
+	kept := keepMostSignificant([]significances{significance(algorithm, s.TrackPoint)}, points)
+	out.TrackPoint = keepPoints(s.TrackPoint, kept[0])
+	return out
+}
+
+// Simplify returns the track with each of its segments simplified
+func (t *Track) Simplify(algorithm SimplifyAlgorithm, tolerance Metres) Track {
+	out := *t
+	out.TrackSegments = make([]TrackSegment, len(t.TrackSegments))
+	for i := range t.TrackSegments {
+		out.TrackSegments[i] = t.TrackSegments[i].Simplify(algorithm, tolerance)
+	}
+	return out
+}
+
+// SimplifyTo returns the track with at most the given number of points in all its
+// segments together, keeping the most significant ones. The first and last points of
+// every segment are always kept.
+func (t *Track) SimplifyTo(algorithm SimplifyAlgorithm, points int) Track {
+	segments := make([]significances, len(t.TrackSegments))
+	for i := range t.TrackSegments {
+		segments[i] = significance(algorithm, t.TrackSegments[i].TrackPoint)
+	}
+	kept := keepMostSignificant(segments, points)
+
+	out := *t
+	out.TrackSegments = make([]TrackSegment, len(t.TrackSegments))
+	for i := range t.TrackSegments {
+		out.TrackSegments[i] = t.TrackSegments[i]
+		out.TrackSegments[i].TrackPoint = keepPoints(t.TrackSegments[i].TrackPoint, kept[i])
+	}
+	return out
+}
+
+// Simplify returns the route without the route points which can be removed within the
+// tolerance
+func (r *Route) Simplify(algorithm SimplifyAlgorithm, tolerance Metres) Route {
+	out := *r
+	out.RoutePoints = keepPoints(r.RoutePoints, significance(algorithm, r.RoutePoints).above(tolerance))
+	return out
+}
+
+// SimplifyTo returns the route with at most the given number of route points
+func (r *Route) SimplifyTo(algorithm SimplifyAlgorithm, points int) Route {
+	out := *r
+	kept := keepMostSignificant([]significances{significance(algorithm, r.RoutePoints)}, points)
+	out.RoutePoints = keepPoints(r.RoutePoints, kept[0])
+	return out
+}
+
+// significances holds, for each point, the largest tolerance for which it is kept.
+// They are nested: a point kept for a tolerance is kept for every smaller one, so that
+// keeping the most significant points always gives a valid simplification.
+type significances []float64
+
+// above returns which points are kept for a tolerance
+func (s significances) above(tolerance Metres) []bool {
+	kept := make([]bool, len(s))
+	for i := range s {
+		kept[i] = s[i] > float64(tolerance)
+	}
+	return kept
+}
+
+// keepMostSignificant returns which points to keep, in each list, to have at most max
+// points in total, or just the end points when there are more of them than that
+func keepMostSignificant(lists []significances, max int) [][]bool {
+	type ranked struct {
+		list, index  int
+		significance float64
+	}
+
+	all := []ranked{}
+	kept := make([][]bool, len(lists))
+	for i, list := range lists {
+		kept[i] = make([]bool, len(list))
+		for j := range list {
+			all = append(all, ranked{i, j, list[j]})
+		}
+	}
+	sort.SliceStable(all, func(a, b int) bool {
+		return all[a].significance > all[b].significance
+	})
+
+	for n, point := range all {
+		if n >= max && !math.IsInf(point.significance, 1) {
+			break
+		}
+		kept[point.list][point.index] = true
+	}
+	return kept
+}
+
+// keepPoints returns a copy of the points which are kept
+func keepPoints[P any](points []P, kept []bool) []P {
+	if points == nil {
+		return nil
+	}
+	out := make([]P, 0, len(points))
+	for i := range points {
+		if kept[i] {
+			out = append(out, points[i])
+		}
+	}
+	return out
+}
+
+// significance computes the significance of each point with the algorithm
+func significance[P Position](algorithm SimplifyAlgorithm, points []P) significances {
+	xy := project(points)
+	if algorithm == VisvalingamWhyatt {
+		return visvalingamWhyatt(xy)
+	}
+	return douglasPeucker(xy)
+}
+
+// planar is a position projected on a plane, in metres
+type planar struct {
+	x, y float64
+}
+
+// project maps the points on a plane tangent to the earth around their mean latitude,
+// which is accurate enough at the scale of the distances between neighbouring points.
+// Longitudes are unwrapped so that tracks crossing the antimeridian stay continuous.
+func project[P Position](points []P) []planar {
+	if len(points) == 0 {
+		return nil
+	}
+
+	meanLatitude := 0.0
+	for i := range points {
+		lat, _ := points[i].LatLon()
+		meanLatitude += float64(lat)
+	}
+	meanLatitude /= float64(len(points))
+	scale := math.Cos(Latitude(meanLatitude).Radians())
+
+	xy := make([]planar, len(points))
+	previous := 0.0
+	for i := range points {
+		lat, lon := points[i].LatLon()
+		longitude := float64(lon)
+		if i > 0 {
+			longitude = previous + normaliseLongitude(longitude-previous)
+		}
+		previous = longitude
+		xy[i] = planar{
+			x: earthRadius * Longitude(longitude).Radians() * scale,
+			y: earthRadius * lat.Radians(),
+		}
+	}
+	return xy
+}
+
+// segmentDistance returns the distance from p to the segment between a and b
+func segmentDistance(p, a, b planar) float64 {
+	dx, dy := b.x-a.x, b.y-a.y
+	length := dx*dx + dy*dy
+	if length == 0 {
+		return math.Hypot(p.x-a.x, p.y-a.y)
+	}
+	t := math.Max(0, math.Min(1, ((p.x-a.x)*dx+(p.y-a.y)*dy)/length))
+	return math.Hypot(p.x-(a.x+t*dx), p.y-(a.y+t*dy))
+}
+
+// triangleArea returns the area of the triangle formed by three points
+func triangleArea(a, b, c planar) float64 {
+	return math.Abs((b.x-a.x)*(c.y-a.y)-(c.x-a.x)*(b.y-a.y)) / 2
+}
+
+// douglasPeucker gives each point the distance at which it splits its range, capped by
+// the significance of the point which split the enclosing range
+func douglasPeucker(xy []planar) significances {
+	s := make(significances, len(xy))
+	if len(xy) == 0 {
+		return s
+	}
+	s[0], s[len(xy)-1] = math.Inf(1), math.Inf(1)
+
+	type span struct {
+		first, last int
+		cap         float64
+	}
+	stack := []span{{0, len(xy) - 1, math.Inf(1)}}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current.last-current.first < 2 {
+			continue
+		}
+
+		furthest, distance := current.first+1, -1.0
+		for i := current.first + 1; i < current.last; i++ {
+			if d := segmentDistance(xy[i], xy[current.first], xy[current.last]); d > distance {
+				furthest, distance = i, d
+			}
+		}
+
+		s[furthest] = math.Min(distance, current.cap)
+		stack = append(stack,
+			span{current.first, furthest, s[furthest]},
+			span{furthest, current.last, s[furthest]},
+		)
+	}
+	return s
+}
+
+// visvalingamWhyatt removes points by increasing triangle area, giving each the square
+// root of the area it is removed at. Areas never decrease along the way, so that
+// removing a point doesn't make its neighbours less significant than it was.
+func visvalingamWhyatt(xy []planar) significances {
+	s := make(significances, len(xy))
+	if len(xy) == 0 {
+		return s
+	}
+	s[0], s[len(xy)-1] = math.Inf(1), math.Inf(1)
+	if len(xy) < 3 {
+		return s
+	}
+
+	previous := make([]int, len(xy))
+	next := make([]int, len(xy))
+	triangles := make(triangleHeap, 0, len(xy)-2)
+	items := make([]*triangle, len(xy))
+	for i := 1; i < len(xy)-1; i++ {
+		previous[i], next[i] = i-1, i+1
+		items[i] = &triangle{point: i, area: triangleArea(xy[i-1], xy[i], xy[i+1]), index: len(triangles)}
+		triangles = append(triangles, items[i])
+	}
+	heap.Init(&triangles)
+
+	update := func(i int) {
+		if items[i] == nil {
+			return
+		}
+		items[i].area = triangleArea(xy[previous[i]], xy[i], xy[next[i]])
+		heap.Fix(&triangles, items[i].index)
+	}
+
+	removed := 0.0
+	for triangles.Len() > 0 {
+		smallest := heap.Pop(&triangles).(*triangle)
+		i := smallest.point
+		items[i] = nil
+
+		removed = math.Max(removed, smallest.area)
+		s[i] = math.Sqrt(removed)
+
+		before, after := previous[i], next[i]
+		next[before], previous[after] = after, before
+		update(before)
+		update(after)
+	}
+	return s
+}
+
+// triangle is a point with the area of the triangle it forms with its neighbours
+type triangle struct {
+	point int
+	area  float64
+	index int
+}
+
+// triangleHeap orders triangles by area, then by position for equal areas
+type triangleHeap []*triangle
+
+func (h triangleHeap) Len() int { return len(h) }
+
+func (h triangleHeap) Less(i, j int) bool {
+	if h[i].area != h[j].area {
+		return h[i].area < h[j].area
+	}
+	return h[i].point < h[j].point
+}
+
+func (h triangleHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *triangleHeap) Push(x any) {
+	item := x.(*triangle)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *triangleHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
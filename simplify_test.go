@@ -0,0 +1,87 @@
+package gpx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+// corner returns a segment going east then north, with the corner at index 5
+func corner() gpx.TrackSegment {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	segment := gpx.TrackSegment{}
+	for i := 0; i <= 10; i++ {
+		lat, lon := 0.0, float64(i)*0.001
+		if i > 5 {
+			lat, lon = float64(i-5)*0.001, 0.005
+		}
+		segment.TrackPoint = append(segment.TrackPoint, trackPoint(lat, lon, float64(i), start.Add(time.Duration(i)*time.Second)))
+	}
+	segment.TrackPoint[5].Extensions = &gpx.TrackPointExtensions{TrackPointExtensions: &gpx.TrackPointExtension{HeartRate: 150}}
+	return segment
+}
+
+func Test_SimplifyTrackSegment(t *testing.T) {
+	segment := corner()
+
+	for _, algorithm := range []gpx.SimplifyAlgorithm{gpx.DouglasPeucker, gpx.VisvalingamWhyatt} {
+		simplified := segment.Simplify(algorithm, 1)
+		require.Len(t, simplified.TrackPoint, 3)
+		assert.Equal(t, segment.TrackPoint[0], simplified.TrackPoint[0])
+		assert.Equal(t, segment.TrackPoint[5], simplified.TrackPoint[1])
+		assert.Equal(t, segment.TrackPoint[10], simplified.TrackPoint[2])
+		assert.Equal(t, gpx.BeatsPerMinute(150), simplified.TrackPoint[1].Extensions.TrackPointExtensions.HeartRate)
+
+		simplified = segment.SimplifyTo(algorithm, 3)
+		require.Len(t, simplified.TrackPoint, 3)
+		assert.Equal(t, segment.TrackPoint[5], simplified.TrackPoint[1])
+
+		simplified = segment.SimplifyTo(algorithm, 1)
+		assert.Len(t, simplified.TrackPoint, 2)
+	}
+	assert.Len(t, segment.TrackPoint, 11)
+
+	// the corner is about 393m from the line joining the ends of the segment
+	assert.Len(t, segment.Simplify(gpx.DouglasPeucker, 390).TrackPoint, 3)
+	assert.Len(t, segment.Simplify(gpx.DouglasPeucker, 395).TrackPoint, 2)
+}
+
+func Test_SimplifyTrack(t *testing.T) {
+	track := gpx.Track{Name: "corners", TrackSegments: []gpx.TrackSegment{corner(), corner()}}
+
+	simplified := track.SimplifyTo(gpx.DouglasPeucker, 5)
+	assert.Equal(t, "corners", simplified.Name)
+	require.Len(t, simplified.TrackSegments, 2)
+	assert.Len(t, simplified.TrackSegments[0].TrackPoint, 3)
+	assert.Len(t, simplified.TrackSegments[1].TrackPoint, 2)
+
+	simplified = track.Simplify(gpx.VisvalingamWhyatt, 1)
+	assert.Len(t, simplified.TrackSegments[1].TrackPoint, 3)
+	assert.Len(t, track.TrackSegments[1].TrackPoint, 11)
+
+	g, err := gpx.ParseFile("./samples/strava-1427712053.gpx")
+	require.Nil(t, err)
+	points := g.Tracks[0].TrackSegments[0].TrackPoint
+
+	for _, algorithm := range []gpx.SimplifyAlgorithm{gpx.DouglasPeucker, gpx.VisvalingamWhyatt} {
+		simplified = g.Tracks[0].SimplifyTo(algorithm, 50)
+		kept := simplified.TrackSegments[0].TrackPoint
+		assert.Len(t, kept, 50)
+		assert.Equal(t, points[0], kept[0])
+		assert.Equal(t, points[len(points)-1], kept[len(kept)-1])
+	}
+}
+
+func Test_SimplifyRoute(t *testing.T) {
+	route := gpx.Route{}
+	for _, point := range corner().TrackPoint {
+		route.RoutePoints = append(route.RoutePoints, gpx.RoutePoint{Latitude: point.Latitude, Longitude: point.Longitude})
+	}
+
+	assert.Len(t, route.Simplify(gpx.DouglasPeucker, 1).RoutePoints, 3)
+	assert.Len(t, route.SimplifyTo(gpx.VisvalingamWhyatt, 2).RoutePoints, 2)
+	assert.Empty(t, (&gpx.Route{}).Simplify(gpx.DouglasPeucker, 1).RoutePoints)
+}
@@ -0,0 +1,199 @@
+package gpx
+
+import (
+	"time"
+)
+
+// Crop returns the segment with only the points from one time to another, both
+// included. A zero time leaves that end open. Points without a timestamp are kept or
+// removed along with the point before them.
+func (s *TrackSegment) Crop(from, to time.Time) TrackSegment {
+	times := pointTimes(s.TrackPoint)
+	kept := make([]bool, len(times))
+	for i, t := range times {
+		kept[i] = (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+	}
+
+	out := *s
+	out.TrackPoint = keepPoints(s.TrackPoint, kept)
+	return out
+}
+
+// Trim returns the segment without the points in its first start metres and in its
+// last end metres
+func (s *TrackSegment) Trim(start, end Metres) TrackSegment {
+	distances := pointDistances(s.TrackPoint)
+	total := Metres(0)
+	if len(distances) > 0 {
+		total = distances[len(distances)-1]
+	}
+
+	out := *s
+	out.TrackPoint = keepPoints(s.TrackPoint, keepBetween(distances, start, total-end))
+	return out
+}
+
+// SplitAt cuts the segment in two: the points before a time, and the points from that
+// time on. Points without a timestamp go along with the point before them.
+func (s *TrackSegment) SplitAt(at time.Time) (TrackSegment, TrackSegment) {
+	times := pointTimes(s.TrackPoint)
+	cut := len(times)
+	for i, t := range times {
+		if !t.Before(at) {
+			cut = i
+			break
+		}
+	}
+	return s.slice(0, cut), s.slice(cut, len(s.TrackPoint))
+}
+
+// SplitGaps cuts the segment wherever two neighbouring points are more than maxTime
+// or more than maxDistance apart. A limit which isn't positive is ignored, and so are
+// time gaps next to points without a timestamp.
+func (s *TrackSegment) SplitGaps(maxTime time.Duration, maxDistance Metres) []TrackSegment {
+	segments := []TrackSegment{}
+	first := 0
+	for i := 1; i < len(s.TrackPoint); i++ {
+		previous, point := &s.TrackPoint[i-1], &s.TrackPoint[i]
+		gap := maxDistance > 0 && Distance(previous, point) > maxDistance
+		if maxTime > 0 && !previous.Timestamp.IsZero() && !point.Timestamp.IsZero() {
+			gap = gap || point.Timestamp.Sub(previous.Timestamp.Time) > maxTime
+		}
+		if gap {
+			segments = append(segments, s.slice(first, i))
+			first = i
+		}
+	}
+	return append(segments, s.slice(first, len(s.TrackPoint)))
+}
+
+// Bounds returns the bounds of the points of the segment, or nil if there are none
+func (s *TrackSegment) Bounds() *Bounds {
+	g := GPX{Tracks: []Track{{TrackSegments: []TrackSegment{*s}}}}
+	return g.ComputeBounds()
+}
+
+// slice returns the segment with a copy of some of its points
+func (s *TrackSegment) slice(from, to int) TrackSegment {
+	out := *s
+	out.TrackPoint = append([]TrackPoint(nil), s.TrackPoint[from:to]...)
+	return out
+}
+
+// Crop returns the track with only the points from one time to another, see
+// TrackSegment.Crop. Segments left without points are removed.
+func (t *Track) Crop(from, to time.Time) Track {
+	out := *t
+	out.TrackSegments = nil
+	for i := range t.TrackSegments {
+		out.TrackSegments = appendSegment(out.TrackSegments, t.TrackSegments[i].Crop(from, to))
+	}
+	return out
+}
+
+// Trim returns the track without the points in its first start metres and in its last
+// end metres. Distance between two segments is not counted, and segments left without
+// points are removed.
+func (t *Track) Trim(start, end Metres) Track {
+	distances := make([][]Metres, len(t.TrackSegments))
+	total := Metres(0)
+	for i := range t.TrackSegments {
+		distances[i] = pointDistances(t.TrackSegments[i].TrackPoint)
+		for j := range distances[i] {
+			distances[i][j] += total
+		}
+		if n := len(distances[i]); n > 0 {
+			total = distances[i][n-1]
+		}
+	}
+
+	out := *t
+	out.TrackSegments = nil
+	for i := range t.TrackSegments {
+		segment := t.TrackSegments[i]
+		segment.TrackPoint = keepPoints(segment.TrackPoint, keepBetween(distances[i], start, total-end))
+		out.TrackSegments = appendSegment(out.TrackSegments, segment)
+	}
+	return out
+}
+
+// SplitAt cuts the track in two at a time, see TrackSegment.SplitAt. Both tracks keep
+// the name, type, links and extensions of the track, and segments left without points
+// are removed.
+func (t *Track) SplitAt(at time.Time) (Track, Track) {
+	before, after := *t, *t
+	before.TrackSegments, after.TrackSegments = nil, nil
+	for i := range t.TrackSegments {
+		first, second := t.TrackSegments[i].SplitAt(at)
+		before.TrackSegments = appendSegment(before.TrackSegments, first)
+		after.TrackSegments = appendSegment(after.TrackSegments, second)
+	}
+	return before, after
+}
+
+// SplitGaps returns the track with its segments cut wherever there is a gap, see
+// TrackSegment.SplitGaps
+func (t *Track) SplitGaps(maxTime time.Duration, maxDistance Metres) Track {
+	out := *t
+	out.TrackSegments = nil
+	for i := range t.TrackSegments {
+		for _, segment := range t.TrackSegments[i].SplitGaps(maxTime, maxDistance) {
+			out.TrackSegments = appendSegment(out.TrackSegments, segment)
+		}
+	}
+	return out
+}
+
+// Bounds returns the bounds of the points of the track, or nil if there are none
+func (t *Track) Bounds() *Bounds {
+	g := GPX{Tracks: []Track{*t}}
+	return g.ComputeBounds()
+}
+
+// WithTracks returns a copy of the file with other tracks, like ones which have been
+// cropped or split, and with Metadata.Bounds recomputed
+func (g *GPX) WithTracks(tracks ...Track) *GPX {
+	out := *g
+	out.Tracks = tracks
+	out.Metadata.Bounds = out.ComputeBounds()
+	return &out
+}
+
+// appendSegment appends a segment unless it has no points
+func appendSegment(segments []TrackSegment, segment TrackSegment) []TrackSegment {
+	if len(segment.TrackPoint) == 0 {
+		return segments
+	}
+	return append(segments, segment)
+}
+
+// pointTimes returns the timestamp of each point, or that of the point before it when
+// it has none
+func pointTimes(points []TrackPoint) []time.Time {
+	times := make([]time.Time, len(points))
+	for i := range points {
+		times[i] = points[i].Timestamp.Time
+		if times[i].IsZero() && i > 0 {
+			times[i] = times[i-1]
+		}
+	}
+	return times
+}
+
+// pointDistances returns the distance from the first point to each point along the points
+func pointDistances(points []TrackPoint) []Metres {
+	distances := make([]Metres, len(points))
+	for i := 1; i < len(points); i++ {
+		distances[i] = distances[i-1] + Distance(&points[i-1], &points[i])
+	}
+	return distances
+}
+
+// keepBetween returns which distances are from one distance to another
+func keepBetween(distances []Metres, from, to Metres) []bool {
+	kept := make([]bool, len(distances))
+	for i, distance := range distances {
+		kept[i] = distance >= from && distance <= to
+	}
+	return kept
+}
@@ -0,0 +1,106 @@
+package gpx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+// pause returns a segment of six points going east, with a pause of five minutes
+// after the third one
+func pause() gpx.TrackSegment {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	segment := gpx.TrackSegment{}
+	for i := 0; i < 6; i++ {
+		at := start.Add(time.Duration(i) * 10 * time.Second)
+		if i >= 3 {
+			at = at.Add(5 * time.Minute)
+		}
+		segment.TrackPoint = append(segment.TrackPoint, trackPoint(0, float64(i)*0.001, 0, at))
+	}
+	return segment
+}
+
+func Test_CropTrackSegment(t *testing.T) {
+	segment := pause()
+	start := segment.TrackPoint[0].Timestamp.Time
+
+	cropped := segment.Crop(start.Add(10*time.Second), start.Add(330*time.Second))
+	require.Len(t, cropped.TrackPoint, 3)
+	assert.Equal(t, segment.TrackPoint[1:4], cropped.TrackPoint)
+
+	assert.Len(t, segment.Crop(time.Time{}, start.Add(15*time.Second)).TrackPoint, 2)
+	assert.Len(t, segment.Crop(start.Add(15*time.Second), time.Time{}).TrackPoint, 4)
+	assert.Len(t, segment.TrackPoint, 6)
+
+	trimmed := segment.Trim(100, 150)
+	require.Len(t, trimmed.TrackPoint, 3)
+	assert.Equal(t, segment.TrackPoint[1:4], trimmed.TrackPoint)
+}
+
+func Test_SplitTrackSegment(t *testing.T) {
+	segment := pause()
+	start := segment.TrackPoint[0].Timestamp.Time
+
+	before, after := segment.SplitAt(start.Add(20 * time.Second))
+	assert.Equal(t, segment.TrackPoint[:2], before.TrackPoint)
+	assert.Equal(t, segment.TrackPoint[2:], after.TrackPoint)
+
+	segments := segment.SplitGaps(time.Minute, 0)
+	require.Len(t, segments, 2)
+	assert.Equal(t, segment.TrackPoint[:3], segments[0].TrackPoint)
+	assert.Equal(t, segment.TrackPoint[3:], segments[1].TrackPoint)
+
+	assert.Len(t, segment.SplitGaps(0, 100), 6)
+	assert.Len(t, segment.SplitGaps(0, 0), 1)
+
+	bounds := segments[1].Bounds()
+	require.NotNil(t, bounds)
+	assert.Equal(t, gpx.Longitude(0.003), bounds.MinimumLongitude)
+	assert.Equal(t, gpx.Longitude(0.005), bounds.MaximumLongitude)
+}
+
+func Test_SplitTrack(t *testing.T) {
+	first := pause()
+	second := pause()
+	for i := range second.TrackPoint {
+		second.TrackPoint[i].Latitude = 0.01
+		second.TrackPoint[i].Timestamp = gpx.NewDateTime(second.TrackPoint[i].Timestamp.Add(time.Hour))
+	}
+	track := gpx.Track{
+		Name:          "Ride",
+		Type:          "cycling",
+		Extensions:    &gpx.TrackExtensions{},
+		TrackSegments: []gpx.TrackSegment{first, second},
+	}
+	start := first.TrackPoint[0].Timestamp.Time
+
+	before, after := track.SplitAt(start.Add(time.Hour))
+	assert.Equal(t, "Ride", before.Name)
+	assert.Equal(t, "cycling", after.Type)
+	assert.Equal(t, track.Extensions, after.Extensions)
+	assert.Equal(t, []gpx.TrackSegment{first}, before.TrackSegments)
+	assert.Equal(t, []gpx.TrackSegment{second}, after.TrackSegments)
+
+	split := track.SplitGaps(time.Minute, 0)
+	assert.Equal(t, "Ride", split.Name)
+	assert.Len(t, split.TrackSegments, 4)
+
+	cropped := track.Crop(start.Add(time.Hour), time.Time{})
+	assert.Equal(t, []gpx.TrackSegment{second}, cropped.TrackSegments)
+
+	trimmed := track.Trim(600, 200)
+	require.Len(t, trimmed.TrackSegments, 1)
+	assert.Equal(t, second.TrackPoint[1:4], trimmed.TrackSegments[0].TrackPoint)
+
+	g := &gpx.GPX{Metadata: gpx.Metadata{Name: "Ride"}, Tracks: []gpx.Track{track}}
+	cut := g.WithTracks(after)
+	assert.Equal(t, "Ride", cut.Metadata.Name)
+	require.NotNil(t, cut.Metadata.Bounds)
+	assert.Equal(t, gpx.Latitude(0.01), cut.Metadata.Bounds.MinimumLatitude)
+	assert.Nil(t, g.Metadata.Bounds)
+	assert.Equal(t, track.Bounds(), g.ComputeBounds())
+}
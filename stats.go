@@ -0,0 +1,301 @@
+package gpx
+
+import (
+	"math"
+	"time"
+)
+
+// MovingSpeedThreshold is the speed below which a track is considered to be stopped
+// when computing the moving time
+const MovingSpeedThreshold MetresPerSecond = 0.5
+
+// maxPowerHold is how long a power sample is assumed to last when the next one is late.
+// Longer gaps count as no power at all.
+const maxPowerHold = 5 * time.Second
+
+// normalisedPowerWindow is the length in seconds of the rolling average used for
+// normalised power
+const normalisedPowerWindow = 30
+
+// Stats summarises distance, time, speed and elevation of a track segment, a track
+// or a complete GPX file. Tracks carry the stats of each of their segments, and a
+// GPX file those of each of its tracks.
+type Stats struct {
+	Points int
+
+	// Distance2D ignores elevation, Distance3D takes it into account
+	Distance2D Metres
+	Distance3D Metres
+
+	// ElapsedTime runs from StartTime to EndTime, while MovingTime only counts the
+	// time spent faster than MovingSpeedThreshold
+	StartTime   time.Time
+	EndTime     time.Time
+	ElapsedTime time.Duration
+	MovingTime  time.Duration
+
+	// AverageSpeed is Distance2D over MovingTime
+	AverageSpeed MetresPerSecond
+	MaxSpeed     MetresPerSecond
+
+	// Elevations only come from points which have one, and are zero when none has
+	MinElevation Metres
+	MaxElevation Metres
+	Ascent       Metres
+	Descent      Metres
+
+	// Power is sampled every second. NormalisedPower is the fourth root of the mean of
+	// the fourth power of its 30 seconds rolling average.
+	AveragePower    Watts
+	NormalisedPower Watts
+	MaxPower        Watts
+
+	Tracks   []Stats
+	Segments []Stats
+
+	elevations       int
+	powerSeconds     int
+	powerSum         float64
+	powerWindows     int
+	rollingFourthSum float64
+}
+
+// Stats computes the stats of every track in the file
+func (g *GPX) Stats() Stats {
+	tracks := make([]Stats, len(g.Tracks))
+	for i := range g.Tracks {
+		tracks[i] = g.Tracks[i].Stats()
+	}
+
+	stats := combineStats(tracks)
+	stats.Tracks = tracks
+	return stats
+}
+
+// Stats computes the stats of the track and each of its segments.
+// Distance between two segments is not counted.
+func (t *Track) Stats() Stats {
+	segments := make([]Stats, len(t.TrackSegments))
+	for i := range t.TrackSegments {
+		segments[i] = t.TrackSegments[i].Stats()
+	}
+
+	stats := combineStats(segments)
+	stats.Segments = segments
+	return stats
+}
+
+// Stats computes the stats of the segment
+func (s *TrackSegment) Stats() Stats {
+	stats := Stats{Points: len(s.TrackPoint)}
+
+	// Points without an elevation are skipped, and the climb to the next point with
+	// one is counted from the last point which had one
+	var elevation *float64
+	for i := range s.TrackPoint {
+		point := &s.TrackPoint[i]
+		if point.Elevation != nil {
+			stats.addElevations(Metres(*point.Elevation), Metres(*point.Elevation), 1)
+			if elevation != nil {
+				stats.addClimb(Metres(*point.Elevation - *elevation))
+			}
+			elevation = point.Elevation
+		}
+		stats.addTime(point.Timestamp.Time)
+		if i == 0 {
+			continue
+		}
+
+		previous := &s.TrackPoint[i-1]
+		distance := float64(Distance(previous, point))
+		climb := 0.0
+		if previous.Elevation != nil && point.Elevation != nil {
+			climb = *point.Elevation - *previous.Elevation
+		}
+
+		stats.Distance2D += Metres(distance)
+		stats.Distance3D += Metres(math.Hypot(distance, climb))
+
+		if previous.Timestamp.IsZero() || point.Timestamp.IsZero() {
+			continue
+		}
+		duration := point.Timestamp.Sub(previous.Timestamp.Time)
+		if duration <= 0 {
+			continue
+		}
+		speed := MetresPerSecond(distance / duration.Seconds())
+		if speed > stats.MaxSpeed {
+			stats.MaxSpeed = speed
+		}
+		if speed >= MovingSpeedThreshold {
+			stats.MovingTime += duration
+		}
+	}
+
+	stats.addPower(powerSeries(s.TrackPoint))
+	stats.finish()
+	return stats
+}
+
+// combineStats adds up the stats of consecutive segments or tracks
+func combineStats(parts []Stats) Stats {
+	stats := Stats{}
+	for _, part := range parts {
+		if part.Points == 0 {
+			continue
+		}
+
+		stats.addElevations(part.MinElevation, part.MaxElevation, part.elevations)
+		stats.addTime(part.StartTime)
+		stats.addTime(part.EndTime)
+
+		stats.Points += part.Points
+		stats.Distance2D += part.Distance2D
+		stats.Distance3D += part.Distance3D
+		stats.MovingTime += part.MovingTime
+		stats.Ascent += part.Ascent
+		stats.Descent += part.Descent
+		if part.MaxSpeed > stats.MaxSpeed {
+			stats.MaxSpeed = part.MaxSpeed
+		}
+
+		stats.powerSeconds += part.powerSeconds
+		stats.powerSum += part.powerSum
+		stats.powerWindows += part.powerWindows
+		stats.rollingFourthSum += part.rollingFourthSum
+		if part.MaxPower > stats.MaxPower {
+			stats.MaxPower = part.MaxPower
+		}
+	}
+
+	stats.finish()
+	return stats
+}
+
+// addElevations adds count elevations ranging from lowest to highest
+func (s *Stats) addElevations(lowest, highest Metres, count int) {
+	if count == 0 {
+		return
+	}
+	if s.elevations == 0 || lowest < s.MinElevation {
+		s.MinElevation = lowest
+	}
+	if s.elevations == 0 || highest > s.MaxElevation {
+		s.MaxElevation = highest
+	}
+	s.elevations += count
+}
+
+func (s *Stats) addClimb(climb Metres) {
+	if climb > 0 {
+		s.Ascent += climb
+	} else {
+		s.Descent -= climb
+	}
+}
+
+func (s *Stats) addTime(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if s.StartTime.IsZero() || t.Before(s.StartTime) {
+		s.StartTime = t
+	}
+	if s.EndTime.IsZero() || t.After(s.EndTime) {
+		s.EndTime = t
+	}
+}
+
+// addPower adds a power series, one second after the other
+func (s *Stats) addPower(series []powerRun) {
+	recent := [normalisedPowerWindow]float64{}
+	window := 0.0
+	i := 0
+	for _, run := range series {
+		if Watts(run.power) > s.MaxPower {
+			s.MaxPower = Watts(run.power)
+		}
+		for n := 0; n < run.seconds; n++ {
+			if n >= normalisedPowerWindow {
+				// The window only holds this power from now on, so the rest of the run
+				// is added at once
+				rest := run.seconds - n
+				s.powerSeconds += rest
+				s.powerSum += run.power * float64(rest)
+				s.powerWindows += rest
+				s.rollingFourthSum += math.Pow(run.power, 4) * float64(rest)
+				i += rest
+				break
+			}
+
+			s.powerSeconds++
+			s.powerSum += run.power
+			window += run.power - recent[i%normalisedPowerWindow]
+			recent[i%normalisedPowerWindow] = run.power
+			if i >= normalisedPowerWindow-1 {
+				s.powerWindows++
+				s.rollingFourthSum += math.Pow(window/normalisedPowerWindow, 4)
+			}
+			i++
+		}
+	}
+}
+
+func (s *Stats) finish() {
+	s.ElapsedTime = s.EndTime.Sub(s.StartTime)
+	if s.MovingTime > 0 {
+		s.AverageSpeed = MetresPerSecond(float64(s.Distance2D) / s.MovingTime.Seconds())
+	}
+	if s.powerSeconds > 0 {
+		s.AveragePower = Watts(math.Round(s.powerSum / float64(s.powerSeconds)))
+	}
+	if s.powerWindows > 0 {
+		s.NormalisedPower = Watts(math.Round(math.Pow(s.rollingFourthSum/float64(s.powerWindows), 0.25)))
+	}
+}
+
+// powerRun is a power held for a number of seconds
+type powerRun struct {
+	power   float64
+	seconds int
+}
+
+// powerSeries returns the power of consecutive points as runs of seconds, or nil if
+// none of them has any. Points without time are taken to be one second apart.
+func powerSeries(points []TrackPoint) []powerRun {
+	found := false
+	for i := range points {
+		if points[i].power() > 0 {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	series := []powerRun{}
+	for i := range points {
+		seconds := 1
+		if i+1 < len(points) && !points[i].Timestamp.IsZero() && !points[i+1].Timestamp.IsZero() {
+			seconds = int(points[i+1].Timestamp.Sub(points[i].Timestamp.Time).Round(time.Second) / time.Second)
+		}
+
+		hold := min(seconds, int(maxPowerHold/time.Second))
+		if hold > 0 {
+			series = append(series, powerRun{power: float64(points[i].power()), seconds: hold})
+		}
+		if seconds > hold {
+			series = append(series, powerRun{seconds: seconds - hold})
+		}
+	}
+	return series
+}
+
+// power returns the power recorded at the point, if any
+func (p *TrackPoint) power() Watts {
+	if p.Extensions == nil {
+		return 0
+	}
+	return p.Extensions.Power
+}
@@ -0,0 +1,165 @@
+package gpx_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func trackPoint(lat, lon, ele float64, t time.Time) gpx.TrackPoint {
+	return gpx.TrackPoint{
+		Latitude:  gpx.Latitude(lat),
+		Longitude: gpx.Longitude(lon),
+		Elevation: &ele,
+		Timestamp: gpx.NewDateTime(t),
+	}
+}
+
+func Test_TrackSegmentStats(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	segment := gpx.TrackSegment{TrackPoint: []gpx.TrackPoint{
+		trackPoint(0, 0, 10, start),
+		trackPoint(0, 0.001, 20, start.Add(10*time.Second)),
+		trackPoint(0, 0.002, 15, start.Add(20*time.Second)),
+		trackPoint(0, 0.002, 15, start.Add(80*time.Second)),
+	}}
+
+	stats := segment.Stats()
+	assert.Equal(t, 4, stats.Points)
+	assert.InDelta(t, 222.39, float64(stats.Distance2D), 0.01)
+	assert.InDelta(t, 222.95, float64(stats.Distance3D), 0.01)
+	assert.Equal(t, 80*time.Second, stats.ElapsedTime)
+	assert.Equal(t, 20*time.Second, stats.MovingTime)
+	assert.InDelta(t, 11.12, float64(stats.AverageSpeed), 0.01)
+	assert.InDelta(t, 11.12, float64(stats.MaxSpeed), 0.01)
+	assert.Equal(t, gpx.Metres(10), stats.MinElevation)
+	assert.Equal(t, gpx.Metres(20), stats.MaxElevation)
+	assert.Equal(t, gpx.Metres(10), stats.Ascent)
+	assert.Equal(t, gpx.Metres(5), stats.Descent)
+}
+
+func Test_TrackStats(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	track := gpx.Track{TrackSegments: []gpx.TrackSegment{
+		{TrackPoint: []gpx.TrackPoint{
+			trackPoint(0, 0, 10, start),
+			trackPoint(0, 0.001, 20, start.Add(10*time.Second)),
+		}},
+		{},
+		{TrackPoint: []gpx.TrackPoint{
+			trackPoint(1, 0, 5, start.Add(time.Hour)),
+			trackPoint(1, 0.001, 5, start.Add(time.Hour+20*time.Second)),
+		}},
+	}}
+
+	stats := track.Stats()
+	require.Len(t, stats.Segments, 3)
+	assert.Equal(t, 4, stats.Points)
+	assert.InDelta(t, float64(stats.Segments[0].Distance2D+stats.Segments[2].Distance2D), float64(stats.Distance2D), 0.0001)
+	assert.Equal(t, time.Hour+20*time.Second, stats.ElapsedTime)
+	assert.Equal(t, 30*time.Second, stats.MovingTime)
+	assert.Equal(t, gpx.Metres(5), stats.MinElevation)
+	assert.Equal(t, gpx.Metres(20), stats.MaxElevation)
+	assert.Equal(t, stats.Segments[0].MaxSpeed, stats.MaxSpeed)
+}
+
+func Test_StatsWithoutElevation(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	missing := trackPoint(0, 0.001, 0, start.Add(10*time.Second))
+	missing.Elevation = nil
+	track := gpx.Track{TrackSegments: []gpx.TrackSegment{
+		{TrackPoint: []gpx.TrackPoint{
+			trackPoint(0, 0, 100, start),
+			missing,
+			trackPoint(0, 0.002, 110, start.Add(20*time.Second)),
+		}},
+		{TrackPoint: []gpx.TrackPoint{missing}},
+	}}
+
+	stats := track.Stats()
+	assert.Equal(t, gpx.Metres(100), stats.Segments[0].MinElevation)
+	assert.Equal(t, gpx.Metres(110), stats.Segments[0].MaxElevation)
+	assert.Equal(t, gpx.Metres(10), stats.Segments[0].Ascent)
+	assert.Equal(t, gpx.Metres(0), stats.Segments[0].Descent)
+	assert.Equal(t, stats.Segments[0].Distance2D, stats.Segments[0].Distance3D)
+
+	// A segment without any elevation doesn't lower the minimum of the track
+	assert.Equal(t, gpx.Metres(100), stats.MinElevation)
+	assert.Equal(t, gpx.Metres(110), stats.MaxElevation)
+	assert.Equal(t, gpx.Metres(10), stats.Ascent)
+}
+
+func Test_GPXStats(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/strava-1427712053.gpx")
+	require.Nil(t, err)
+
+	stats := g.Stats()
+	require.Len(t, stats.Tracks, 1)
+	assert.Equal(t, stats.Tracks[0].Points, stats.Points)
+	assert.Equal(t, len(g.Tracks[0].TrackSegments[0].TrackPoint), stats.Points)
+	assert.Equal(t, stats.Tracks[0].Distance2D, stats.Distance2D)
+	assert.True(t, stats.Distance3D >= stats.Distance2D)
+	assert.True(t, stats.MovingTime <= stats.ElapsedTime)
+	assert.Equal(t, g.Tracks[0].TrackSegments[0].TrackPoint[0].Timestamp.Time, stats.StartTime)
+}
+
+func Test_PowerStats(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	segment := gpx.TrackSegment{}
+	for i := 0; i < 60; i++ {
+		point := trackPoint(0, float64(i)*0.0001, 0, start.Add(time.Duration(i)*time.Second))
+		if i >= 30 {
+			point.Extensions = &gpx.TrackPointExtensions{Power: 400}
+		}
+		segment.TrackPoint = append(segment.TrackPoint, point)
+	}
+
+	stats := segment.Stats()
+	assert.Equal(t, gpx.Watts(200), stats.AveragePower)
+	assert.Equal(t, gpx.Watts(271), stats.NormalisedPower)
+	assert.Equal(t, gpx.Watts(400), stats.MaxPower)
+
+	track := gpx.Track{TrackSegments: []gpx.TrackSegment{segment, {}, segment}}
+	stats = track.Stats()
+	assert.Equal(t, gpx.Watts(200), stats.AveragePower)
+	assert.Equal(t, gpx.Watts(271), stats.NormalisedPower)
+	assert.Equal(t, gpx.Watts(400), stats.MaxPower)
+}
+
+func Test_PowerStatsWithGaps(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	segment := gpx.TrackSegment{TrackPoint: []gpx.TrackPoint{
+		trackPoint(0, 0, 0, start),
+		trackPoint(0, 0, 0, start.Add(10*time.Second)),
+	}}
+	segment.TrackPoint[0].Extensions = &gpx.TrackPointExtensions{Power: 300}
+
+	stats := segment.Stats()
+	assert.Equal(t, gpx.Watts(136), stats.AveragePower)
+	assert.Equal(t, gpx.Watts(0), stats.NormalisedPower)
+
+	g, err := gpx.ParseFile("./samples/mapbox.gpx")
+	require.Nil(t, err)
+	assert.Equal(t, gpx.Watts(0), g.Stats().AveragePower)
+
+	// Long gaps count as seconds without power, without holding a value for each
+	segment = gpx.TrackSegment{}
+	for i := 0; i < 60; i++ {
+		segment.TrackPoint = append(segment.TrackPoint, trackPoint(0, 0, 0, start.Add(time.Duration(i)*time.Second)))
+	}
+	segment.TrackPoint = append(segment.TrackPoint, trackPoint(0, 0, 0, start.Add(159*time.Second)))
+	for i := range segment.TrackPoint {
+		segment.TrackPoint[i].Extensions = &gpx.TrackPointExtensions{Power: 400}
+	}
+	stats = segment.Stats()
+	assert.Equal(t, gpx.Watts(163), stats.AveragePower)
+	assert.Equal(t, gpx.Watts(298), stats.NormalisedPower)
+
+	segment.TrackPoint[60].Timestamp = gpx.NewDateTime(start.AddDate(20, 0, 0))
+	stats = segment.Stats()
+	assert.Equal(t, gpx.Watts(0), stats.AveragePower)
+	assert.Equal(t, gpx.Watts(400), stats.MaxPower)
+}
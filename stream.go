@@ -0,0 +1,354 @@
+package gpx
+
+import (
+	"io"
+	"iter"
+
+	xml "github.com/Zauberstuhl/go-xml"
+)
+
+// ElementKind tells which part of a GPX document an Element holds
+type ElementKind int
+
+const (
+	// MetadataElement holds the metadata of the document
+	MetadataElement ElementKind = iota + 1
+	// WayPointElement holds a single waypoint
+	WayPointElement
+	// RouteElement holds a complete route with its route points
+	RouteElement
+	// TrackElement holds a track without its segments
+	TrackElement
+	// TrackPointElement holds a single track point
+	TrackPointElement
+)
+
+// Element is a single item read from a GPX document while streaming it.
+// Only the field matching Kind is set, except for track points which also carry
+// the Track they belong to.
+type Element struct {
+	Kind       ElementKind
+	Metadata   *Metadata
+	WayPoint   *WayPoint
+	Route      *Route
+	Track      *Track
+	TrackPoint *TrackPoint
+
+	// TrackIndex, SegmentIndex and PointIndex locate a track point in the document.
+	// TrackIndex is also set for TrackElement.
+	TrackIndex   int
+	SegmentIndex int
+	PointIndex   int
+}
+
+// Elements streams the document one element at a time instead of unmarshalling it
+// completely, so that memory use does not grow with the size of the file.
+// Tracks are yielded once their header (name, type, extensions...) has been read and
+// without any segments, followed by each of their track points.
+// GPX 1.0 files are converted as they are read, with the fields found at the top of
+// the document yielded as metadata before the first waypoint, route or track.
+// Errors are the same as for Decode. With the Strict option, streaming stops with a
+// *ValidationError before yielding an element once issues have been found.
+func (dec *Decoder) Elements() iter.Seq2[Element, error] {
+	return func(yield func(Element, error) bool) {
+		yield = dec.report(yield)
+		trackIndex := -1
+		header := &gpx10{}
+		yieldHeader := func() bool {
+			if !header.hasMetadata() {
+				return true
+			}
+			metadata := header.metadata()
+			header = &gpx10{}
+			return yield(Element{Kind: MetadataElement, Metadata: &metadata}, nil)
+		}
+
+		for {
+			token, err := dec.decoder.Token()
+			if err == io.EOF {
+				if yieldHeader() {
+					if err := dec.invalid(); err != nil {
+						yield(Element{}, err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				yield(Element{}, err)
+				return
+			}
+
+			start, ok := token.(xml.StartElement)
+			if !ok {
+				continue
+			}
+
+			if dec.version10 {
+				if field := header.field(start.Name.Local); field != nil {
+					if err := dec.decoder.DecodeElement(field, &start); err != nil {
+						yield(Element{}, err)
+						return
+					}
+					continue
+				}
+				if !yieldHeader() {
+					return
+				}
+			}
+
+			var element Element
+			switch start.Name.Local {
+			case "gpx":
+				if err := checkVersion(start); err != nil {
+					yield(Element{}, err)
+					return
+				}
+				dec.version10 = isVersion10(start)
+				continue
+			case "metadata":
+				element = Element{Kind: MetadataElement, Metadata: &Metadata{}}
+				err = dec.decoder.DecodeElement(element.Metadata, &start)
+			case "wpt":
+				element = Element{Kind: WayPointElement, WayPoint: &WayPoint{}}
+				err = dec.decodeWayPoint(element.WayPoint, start)
+			case "rte":
+				element = Element{Kind: RouteElement, Route: &Route{}}
+				err = dec.decodeRoute(element.Route, start)
+			case "trk":
+				trackIndex++
+				if !dec.streamTrack(trackIndex, yield) {
+					return
+				}
+				continue
+			default:
+				if err = dec.decoder.Skip(); err == nil {
+					continue
+				}
+			}
+
+			if err != nil {
+				yield(Element{}, err)
+				return
+			}
+			if !yield(element, nil) {
+				return
+			}
+		}
+	}
+}
+
+// report wraps yield to describe errors, and to report the issues found before each
+// element with the Strict option
+func (dec *Decoder) report(yield func(Element, error) bool) func(Element, error) bool {
+	failed := false
+	return func(element Element, err error) bool {
+		if failed {
+			return false
+		}
+		if err != nil {
+			err = dec.tracker.wrap(err)
+		} else {
+			err = dec.invalid()
+		}
+		if err != nil {
+			failed = true
+			yield(Element{}, err)
+			return false
+		}
+		return yield(element, nil)
+	}
+}
+
+// TrackPoints streams only the track points of the document, each with its track
+// and position attached
+func (dec *Decoder) TrackPoints() iter.Seq2[Element, error] {
+	return func(yield func(Element, error) bool) {
+		for element, err := range dec.Elements() {
+			if err != nil {
+				yield(element, err)
+				return
+			}
+			if element.Kind != TrackPointElement {
+				continue
+			}
+			if !yield(element, nil) {
+				return
+			}
+		}
+	}
+}
+
+// streamTrack reads a trk element whose start tag has already been consumed.
+// It returns false once the caller should stop.
+func (dec *Decoder) streamTrack(trackIndex int, yield func(Element, error) bool) bool {
+	track := &Track{}
+	yielded := false
+	yieldTrack := func() bool {
+		if yielded {
+			return true
+		}
+		yielded = true
+		return yield(Element{Kind: TrackElement, Track: track, TrackIndex: trackIndex}, nil)
+	}
+
+	segmentIndex := -1
+	for {
+		token, err := dec.nextToken()
+		if err != nil {
+			yield(Element{}, err)
+			return false
+		}
+
+		switch t := token.(type) {
+		case xml.EndElement:
+			return yieldTrack()
+		case xml.StartElement:
+			if t.Name.Local == "trkseg" {
+				if !yieldTrack() {
+					return false
+				}
+				segmentIndex++
+				if !dec.streamTrackSegment(track, trackIndex, segmentIndex, yield) {
+					return false
+				}
+				continue
+			}
+			if err := dec.decodeTrackField(track, t); err != nil {
+				yield(Element{}, err)
+				return false
+			}
+		}
+	}
+}
+
+// streamTrackSegment reads a trkseg element whose start tag has already been consumed
+func (dec *Decoder) streamTrackSegment(track *Track, trackIndex, segmentIndex int, yield func(Element, error) bool) bool {
+	pointIndex := 0
+	for {
+		token, err := dec.nextToken()
+		if err != nil {
+			yield(Element{}, err)
+			return false
+		}
+
+		switch t := token.(type) {
+		case xml.EndElement:
+			return true
+		case xml.StartElement:
+			if t.Name.Local != "trkpt" {
+				if err := dec.decoder.Skip(); err != nil {
+					yield(Element{}, err)
+					return false
+				}
+				continue
+			}
+
+			point := &TrackPoint{}
+			if err := dec.decodeTrackPoint(point, t); err != nil {
+				yield(Element{}, err)
+				return false
+			}
+			element := Element{
+				Kind:         TrackPointElement,
+				Track:        track,
+				TrackPoint:   point,
+				TrackIndex:   trackIndex,
+				SegmentIndex: segmentIndex,
+				PointIndex:   pointIndex,
+			}
+			if !yield(element, nil) {
+				return false
+			}
+			pointIndex++
+		}
+	}
+}
+
+// decodeTrackField decodes a child of trk other than trkseg into the track
+func (dec *Decoder) decodeTrackField(track *Track, start xml.StartElement) error {
+	switch start.Name.Local {
+	case "name":
+		return dec.decoder.DecodeElement(&track.Name, &start)
+	case "cmt":
+		return dec.decoder.DecodeElement(&track.Comment, &start)
+	case "desc":
+		return dec.decoder.DecodeElement(&track.Description, &start)
+	case "src":
+		return dec.decoder.DecodeElement(&track.Source, &start)
+	case "number":
+		return dec.decoder.DecodeElement(&track.Number, &start)
+	case "type":
+		return dec.decoder.DecodeElement(&track.Type, &start)
+	case "link":
+		link := Link{}
+		if err := dec.decoder.DecodeElement(&link, &start); err != nil {
+			return err
+		}
+		track.Links = append(track.Links, link)
+		return nil
+	case "extensions":
+		track.Extensions = &TrackExtensions{}
+		return dec.decoder.DecodeElement(track.Extensions, &start)
+	case "url", "urlname":
+		if !dec.version10 {
+			break
+		}
+		if len(track.Links) == 0 {
+			track.Links = append(track.Links, Link{})
+		}
+		if start.Name.Local == "url" {
+			return dec.decoder.DecodeElement(&track.Links[0].URL, &start)
+		}
+		return dec.decoder.DecodeElement(&track.Links[0].Text, &start)
+	}
+	return dec.decoder.Skip()
+}
+
+// decodeWayPoint decodes a wpt element, converting it from GPX 1.0 if needed
+func (dec *Decoder) decodeWayPoint(point *WayPoint, start xml.StartElement) error {
+	if !dec.version10 {
+		return dec.decoder.DecodeElement(point, &start)
+	}
+	legacy := point10{}
+	if err := dec.decoder.DecodeElement(&legacy, &start); err != nil {
+		return err
+	}
+	*point = legacy.wayPoint()
+	return nil
+}
+
+// decodeRoute decodes a rte element, converting it from GPX 1.0 if needed
+func (dec *Decoder) decodeRoute(route *Route, start xml.StartElement) error {
+	if !dec.version10 {
+		return dec.decoder.DecodeElement(route, &start)
+	}
+	legacy := route10{}
+	if err := dec.decoder.DecodeElement(&legacy, &start); err != nil {
+		return err
+	}
+	*route = legacy.route()
+	return nil
+}
+
+// decodeTrackPoint decodes a trkpt element, converting it from GPX 1.0 if needed
+func (dec *Decoder) decodeTrackPoint(point *TrackPoint, start xml.StartElement) error {
+	if !dec.version10 {
+		return dec.decoder.DecodeElement(point, &start)
+	}
+	legacy := point10{}
+	if err := dec.decoder.DecodeElement(&legacy, &start); err != nil {
+		return err
+	}
+	*point = legacy.trackPoint()
+	return nil
+}
+
+// nextToken returns the next token, treating the end of the input as an error
+// since it is only used inside elements that have not been closed yet
+func (dec *Decoder) nextToken() (xml.Token, error) {
+	token, err := dec.decoder.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return token, err
+}
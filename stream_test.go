@@ -0,0 +1,96 @@
+package gpx_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_StreamElements(t *testing.T) {
+	file, err := os.Open("./samples/strava-1427712053.gpx")
+	require.Nil(t, err)
+	defer file.Close()
+
+	g, err := gpx.ParseFile("./samples/strava-1427712053.gpx")
+	require.Nil(t, err)
+
+	decoder := gpx.NewDecoder(file)
+
+	kinds := []gpx.ElementKind{}
+	points := 0
+	for element, err := range decoder.Elements() {
+		require.Nil(t, err)
+		if element.Kind != gpx.TrackPointElement {
+			kinds = append(kinds, element.Kind)
+			continue
+		}
+
+		assert.Equal(t, "Really bad GPS", element.Track.Name)
+		assert.Equal(t, 0, element.TrackIndex)
+		assert.Equal(t, 0, element.SegmentIndex)
+		assert.Equal(t, points, element.PointIndex)
+		assert.Equal(t, g.Tracks[0].TrackSegments[0].TrackPoint[points].Latitude, element.TrackPoint.Latitude)
+		points++
+	}
+
+	assert.Equal(t, []gpx.ElementKind{gpx.MetadataElement, gpx.TrackElement}, kinds)
+	assert.Equal(t, len(g.Tracks[0].TrackSegments[0].TrackPoint), points)
+}
+
+func Test_StreamWayPoints(t *testing.T) {
+	file, err := os.Open("./samples/StLouisZoo.gpx")
+	require.Nil(t, err)
+	defer file.Close()
+
+	decoder := gpx.NewDecoder(file)
+
+	names := []string{}
+	for element, err := range decoder.Elements() {
+		require.Nil(t, err)
+		if element.Kind == gpx.WayPointElement {
+			names = append(names, element.WayPoint.Name)
+		}
+	}
+	assert.Equal(t, "Asian Elephant", names[0])
+	assert.Equal(t, "Bactrian Camel", names[1])
+}
+
+func Test_StreamTrackPointsStopEarly(t *testing.T) {
+	file, err := os.Open("./samples/mapbox.gpx")
+	require.Nil(t, err)
+	defer file.Close()
+
+	decoder := gpx.NewDecoder(file)
+
+	points := []*gpx.TrackPoint{}
+	for element, err := range decoder.TrackPoints() {
+		require.Nil(t, err)
+		points = append(points, element.TrackPoint)
+		if len(points) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, gpx.BeatsPerMinute(130), points[0].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.BeatsPerMinute(134), points[1].Extensions.TrackPointExtensions.HeartRate)
+}
+
+func Test_StreamTruncatedDocument(t *testing.T) {
+	decoder := gpx.NewDecoder(strings.NewReader(`<gpx version="1.1"><trk><name>a</name><trkseg><trkpt lat="1" lon="2"></trkpt>`))
+
+	var last error
+	points := 0
+	for element, err := range decoder.TrackPoints() {
+		if err != nil {
+			last = err
+			continue
+		}
+		assert.Equal(t, gpx.Latitude(1), element.TrackPoint.Latitude)
+		points++
+	}
+	assert.Equal(t, 1, points)
+	assert.NotNil(t, last)
+}
@@ -0,0 +1,365 @@
+package gpx
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	xml "github.com/Zauberstuhl/go-xml"
+)
+
+// Namespaces of Training Center XML and of the activity extension it contains
+const (
+	TCXNamespace               = "http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2"
+	ActivityExtensionNamespace = "http://www.garmin.com/xmlschemas/ActivityExtension/v2"
+)
+
+var (
+	tcxNamespace               = namespace{"", TCXNamespace, "http://www.garmin.com/xmlschemas/TrainingCenterDatabasev2.xsd"}
+	activityExtensionNamespace = namespace{"ax", ActivityExtensionNamespace, "http://www.garmin.com/xmlschemas/ActivityExtensionv2.xsd"}
+)
+
+// tcxDialect reads Training Center XML documents
+var tcxDialect = dialect{
+	namespaces: []string{TCXNamespace},
+	extensions: []namespace{activityExtensionNamespace},
+	points:     []string{"Trackpoint"},
+}
+
+// ErrUntimedPoint is returned when writing as TCX a track point without a time, which
+// trackpoints, laps and activities must have
+var ErrUntimedPoint = errors.New("gpx: TCX trackpoints must have a time")
+
+// TCX sports, which only tell running and biking apart
+const (
+	tcxRunning = "Running"
+	tcxBiking  = "Biking"
+	tcxOther   = "Other"
+)
+
+// ParseTCXFile reads a Training Center XML file, see ParseTCX
+func ParseTCXFile(fileName string, opts ...ReadOption) (*GPX, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return &GPX{}, &IOError{Err: err}
+	}
+	defer file.Close()
+	return ParseTCX(file, opts...)
+}
+
+// ParseTCX reads the activities of a Training Center XML document, which can be
+// compressed with gzip, into a GPX document. Every activity becomes a track with the
+// sport as its type, and every lap a segment. The heart rate, cadence and speed of
+// trackpoints go into TrackPointExtension and their power into PowerInWatts.
+// GPX points must have a position, so trackpoints without one, like those of indoor
+// activities, are kept at the last position recorded before them, or the first one
+// after them, and at 0, 0 when the activity has no position at all.
+// Errors are the same as with Decode, and so are the limits set by options.
+func ParseTCX(r io.Reader, opts ...ReadOption) (*GPX, error) {
+	g := GPX{}
+
+	input, err := decompressReader(r)
+	if err != nil {
+		return &g, err
+	}
+
+	t, tokens := newTokenReader(input, newReadOptions(opts), tcxDialect)
+	database := tcxDatabase{}
+	if err := xml.NewTokenDecoder(tokens).Decode(&database); err != nil {
+		return &g, t.wrap(err)
+	}
+
+	g = database.gpx()
+	return &g, nil
+}
+
+// WriteTCX writes the tracks of the document as Training Center XML activities, with
+// a lap for every segment. Tracks without any point are skipped. Laps have the time,
+// distance, maximum speed and heart rate of their segment. ErrUntimedPoint is returned,
+// before anything is written, when a point has no time. Options for the header and
+// the indentation apply as with WriteTo.
+func WriteTCX(w io.Writer, g *GPX, opts ...WriteOption) error {
+	o := newWriteOptions(append(writeDefaults[:len(writeDefaults):len(writeDefaults)], opts...))
+	database, err := newTCXDatabase(g)
+	if err != nil {
+		return err
+	}
+	if o.header {
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent(o.prefix, o.indent)
+	return encoder.Encode(database)
+}
+
+// tcxDatabase is the root element of a Training Center XML document
+type tcxDatabase struct {
+	XMLName    xml.Name      `xml:"TrainingCenterDatabase"`
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+type tcxActivity struct {
+	Sport   string      `xml:"Sport,attr"`
+	ID      DateTime    `xml:"Id"`
+	Laps    []tcxLap    `xml:"Lap"`
+	Notes   string      `xml:"Notes,omitempty"`
+	Creator *tcxCreator `xml:"Creator,omitempty"`
+}
+
+// tcxCreator is only read, as writing it requires the identifiers of a device
+type tcxCreator struct {
+	Name string `xml:"Name"`
+}
+
+type tcxLap struct {
+	StartTime           string        `xml:"StartTime,attr"`
+	TotalTimeSeconds    float64       `xml:"TotalTimeSeconds"`
+	DistanceMeters      float64       `xml:"DistanceMeters"`
+	MaximumSpeed        float64       `xml:"MaximumSpeed,omitempty"`
+	Calories            int           `xml:"Calories"`
+	AverageHeartRateBpm *tcxHeartRate `xml:"AverageHeartRateBpm,omitempty"`
+	MaximumHeartRateBpm *tcxHeartRate `xml:"MaximumHeartRateBpm,omitempty"`
+	Intensity           string        `xml:"Intensity"`
+	TriggerMethod       string        `xml:"TriggerMethod"`
+	Tracks              []tcxTrack    `xml:"Track"`
+	Notes               string        `xml:"Notes,omitempty"`
+}
+
+type tcxTrack struct {
+	Trackpoints []tcxTrackpoint `xml:"Trackpoint"`
+}
+
+type tcxTrackpoint struct {
+	Time           DateTime             `xml:"Time"`
+	Position       *tcxPosition         `xml:"Position,omitempty"`
+	AltitudeMeters *float64             `xml:"AltitudeMeters,omitempty"`
+	DistanceMeters *float64             `xml:"DistanceMeters,omitempty"`
+	HeartRateBpm   *tcxHeartRate        `xml:"HeartRateBpm,omitempty"`
+	Cadence        RevolutionsPerMinute `xml:"Cadence,omitempty"`
+	Extensions     *tcxExtensions       `xml:"Extensions,omitempty"`
+}
+
+type tcxPosition struct {
+	Latitude  Latitude  `xml:"LatitudeDegrees"`
+	Longitude Longitude `xml:"LongitudeDegrees"`
+}
+
+type tcxHeartRate struct {
+	Value BeatsPerMinute `xml:"Value"`
+}
+
+// tcxExtensions holds the extensions of trackpoints from ActivityExtension/v2
+type tcxExtensions struct {
+	TPX *tcxTPX `xml:"ax:TPX,omitempty"`
+}
+
+type tcxTPX struct {
+	Speed      *MetresPerSecond     `xml:"ax:Speed,omitempty"`
+	RunCadence RevolutionsPerMinute `xml:"ax:RunCadence,omitempty"`
+	Watts      Watts                `xml:"ax:Watts,omitempty"`
+}
+
+// MarshalXML writes the root element with the namespaces of TCX and of the activity
+// extension
+func (d tcxDatabase) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type plain tcxDatabase
+
+	start.Name = xml.Name{Local: "TrainingCenterDatabase"}
+	start.Attr = append(start.Attr,
+		xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: tcxNamespace.uri},
+		xml.Attr{Name: xml.Name{Local: "xmlns:" + activityExtensionNamespace.prefix}, Value: activityExtensionNamespace.uri},
+		xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: xsiNamespace},
+		xml.Attr{Name: xml.Name{Local: "xsi:schemaLocation"}, Value: strings.Join([]string{
+			tcxNamespace.uri, tcxNamespace.schema,
+			activityExtensionNamespace.uri, activityExtensionNamespace.schema,
+		}, " ")},
+	)
+	return e.EncodeElement(plain(d), start)
+}
+
+// gpx converts the activities to tracks
+func (d *tcxDatabase) gpx() GPX {
+	g := GPX{Version: "1.1"}
+	for i := range d.Activities {
+		activity := &d.Activities[i]
+		if g.Creator == "" && activity.Creator != nil {
+			g.Creator = activity.Creator.Name
+		}
+		if g.Metadata.Timestamp.IsZero() {
+			g.Metadata.Timestamp = activity.ID
+		}
+
+		track := Track{Type: activity.Sport, Description: activity.Notes}
+		position := activity.firstPosition()
+		for _, lap := range activity.Laps {
+			segment := TrackSegment{}
+			for _, tcxTrack := range lap.Tracks {
+				for _, trackpoint := range tcxTrack.Trackpoints {
+					if trackpoint.Position != nil {
+						position = *trackpoint.Position
+					}
+					segment.TrackPoint = append(segment.TrackPoint, trackpoint.point(position))
+				}
+			}
+			track.TrackSegments = append(track.TrackSegments, segment)
+		}
+		g.Tracks = append(g.Tracks, track)
+	}
+	return g
+}
+
+// firstPosition returns the first position recorded during the activity, or a zero
+// one when there is none
+func (a *tcxActivity) firstPosition() tcxPosition {
+	for _, lap := range a.Laps {
+		for _, track := range lap.Tracks {
+			for _, trackpoint := range track.Trackpoints {
+				if trackpoint.Position != nil {
+					return *trackpoint.Position
+				}
+			}
+		}
+	}
+	return tcxPosition{}
+}
+
+// point converts a trackpoint, at position when it has none
+func (p *tcxTrackpoint) point(position tcxPosition) TrackPoint {
+	point := TrackPoint{
+		Latitude:  position.Latitude,
+		Longitude: position.Longitude,
+		Elevation: p.AltitudeMeters,
+		Timestamp: p.Time,
+	}
+
+	extension := TrackPointExtension{Cadence: p.Cadence}
+	power := Watts(0)
+	if p.HeartRateBpm != nil {
+		extension.HeartRate = p.HeartRateBpm.Value
+	}
+	if p.Extensions != nil && p.Extensions.TPX != nil {
+		extension.Speed = p.Extensions.TPX.Speed
+		if extension.Cadence == 0 {
+			extension.Cadence = p.Extensions.TPX.RunCadence
+		}
+		power = p.Extensions.TPX.Watts
+	}
+
+	if extension != (TrackPointExtension{}) || power != 0 {
+		point.Extensions = &TrackPointExtensions{Power: power}
+		if extension != (TrackPointExtension{}) {
+			point.Extensions.TrackPointExtensions = &extension
+		}
+	}
+	return point
+}
+
+// newTCXDatabase converts the tracks of a document to activities, with the time of
+// their first point as Id. Tracks without any point are skipped, as activities need an
+// Id and a lap.
+func newTCXDatabase(g *GPX) (tcxDatabase, error) {
+	d := tcxDatabase{}
+	for i := range g.Tracks {
+		track := &g.Tracks[i]
+		activity := tcxActivity{Sport: tcxSport(track.Type), Notes: track.Description}
+
+		distance := 0.0
+		for j := range track.TrackSegments {
+			segment := &track.TrackSegments[j]
+			if len(segment.TrackPoint) == 0 {
+				continue
+			}
+			if activity.ID.IsZero() {
+				activity.ID = segment.TrackPoint[0].Timestamp
+			}
+
+			lap, end, err := newTCXLap(segment, activity.Sport, distance)
+			if err != nil {
+				return d, err
+			}
+			activity.Laps = append(activity.Laps, lap)
+			distance = end
+		}
+		if len(activity.Laps) > 0 {
+			d.Activities = append(d.Activities, activity)
+		}
+	}
+	return d, nil
+}
+
+// newTCXLap converts a segment to a lap, with the distance of its trackpoints counted
+// from distance. It returns the lap and the distance at its end, or ErrUntimedPoint.
+func newTCXLap(segment *TrackSegment, sport string, distance float64) (tcxLap, float64, error) {
+	stats := segment.Stats()
+	lap := tcxLap{
+		StartTime:        segment.TrackPoint[0].Timestamp.String(),
+		TotalTimeSeconds: stats.ElapsedTime.Seconds(),
+		DistanceMeters:   float64(stats.Distance2D),
+		MaximumSpeed:     float64(stats.MaxSpeed),
+		Intensity:        "Active",
+		TriggerMethod:    "Manual",
+	}
+
+	track := tcxTrack{}
+	heartRates, maxHeartRate := 0.0, BeatsPerMinute(0)
+	count := 0
+	for i := range segment.TrackPoint {
+		point := &segment.TrackPoint[i]
+		if point.Timestamp.IsZero() {
+			return lap, distance, ErrUntimedPoint
+		}
+		if i > 0 {
+			distance += float64(Distance(&segment.TrackPoint[i-1], point))
+		}
+
+		pointDistance := distance
+		trackpoint := tcxTrackpoint{
+			Time:           point.Timestamp,
+			Position:       &tcxPosition{Latitude: point.Latitude, Longitude: point.Longitude},
+			AltitudeMeters: point.Elevation,
+			DistanceMeters: &pointDistance,
+		}
+
+		values := sensors(*point)
+		tpx := tcxTPX{Speed: values.Speed, Watts: point.power()}
+		if sport == tcxRunning {
+			tpx.RunCadence = values.Cadence
+		} else {
+			trackpoint.Cadence = values.Cadence
+		}
+		if tpx != (tcxTPX{}) {
+			trackpoint.Extensions = &tcxExtensions{TPX: &tpx}
+		}
+		if values.HeartRate != 0 {
+			trackpoint.HeartRateBpm = &tcxHeartRate{Value: values.HeartRate}
+			heartRates += float64(values.HeartRate)
+			maxHeartRate = max(maxHeartRate, values.HeartRate)
+			count++
+		}
+		track.Trackpoints = append(track.Trackpoints, trackpoint)
+	}
+
+	if count > 0 {
+		lap.AverageHeartRateBpm = &tcxHeartRate{Value: BeatsPerMinute(math.Round(heartRates / float64(count)))}
+		lap.MaximumHeartRateBpm = &tcxHeartRate{Value: maxHeartRate}
+	}
+	lap.Tracks = []tcxTrack{track}
+	return lap, distance, nil
+}
+
+// tcxSport returns the TCX sport of a track type, which can also be one of the
+// activity codes Strava writes, 1 for rides and 9 for runs
+func tcxSport(kind string) string {
+	switch strings.ToLower(kind) {
+	case "running", "run", "trail_running", "trail running", "9":
+		return tcxRunning
+	case "biking", "cycling", "ride", "bike", "road_biking", "mountain_biking", "1":
+		return tcxBiking
+	}
+	return tcxOther
+}
@@ -0,0 +1,163 @@
+package gpx_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_ParseTCX(t *testing.T) {
+	g, err := gpx.ParseTCXFile("./samples/activity.tcx")
+	require.Nil(t, err)
+
+	assert.Equal(t, "Edge 530", g.Creator)
+	assert.Equal(t, "2019-06-15T07:30:00.000Z", g.Metadata.Timestamp.String())
+	require.Len(t, g.Tracks, 1)
+	assert.Equal(t, "Biking", g.Tracks[0].Type)
+	require.Len(t, g.Tracks[0].TrackSegments, 2)
+
+	points := g.Tracks[0].TrackSegments[0].TrackPoint
+	require.Len(t, points, 3)
+	assert.Equal(t, gpx.Latitude(47.6062), points[0].Latitude)
+	assert.Equal(t, gpx.Longitude(-122.3321), points[0].Longitude)
+	assert.Equal(t, 52.4, *points[0].Elevation)
+	assert.Equal(t, "2019-06-15T07:30:00.000Z", points[0].Timestamp.String())
+	require.NotNil(t, points[0].Extensions)
+	assert.Equal(t, gpx.BeatsPerMinute(120), points[0].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.RevolutionsPerMinute(85), points[0].Extensions.TrackPointExtensions.Cadence)
+	assert.Equal(t, gpx.MetresPerSecond(6.9), *points[0].Extensions.TrackPointExtensions.Speed)
+	assert.Equal(t, gpx.Watts(210), points[0].Extensions.Power)
+	// The trackpoint without a position is kept at the one before it
+	assert.Equal(t, gpx.Latitude(47.6062), points[1].Latitude)
+	assert.Equal(t, gpx.BeatsPerMinute(121), points[1].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.Watts(225), points[2].Extensions.Power)
+	assert.Equal(t, gpx.MetresPerSecond(7.3), *points[2].Extensions.TrackPointExtensions.Speed)
+	assert.Nil(t, g.Tracks[0].TrackSegments[1].TrackPoint[0].Extensions)
+
+	_, err = gpx.ParseTCX(strings.NewReader("<TrainingCenterDatabase><Activities>"))
+	assert.True(t, errors.Is(err, gpx.ErrSyntax))
+
+	_, err = gpx.ParseTCXFile("./samples/activity.tcx", gpx.MaxPoints(2))
+	assert.True(t, errors.Is(err, gpx.ErrLimitExceeded))
+	_, err = gpx.ParseTCXFile("./samples/activity.tcx", gpx.MaxPoints(4))
+	assert.Nil(t, err)
+
+	// TCX names are only read as such by ParseTCX
+	_, err = gpx.ParseReader(strings.NewReader(`<gpx version="1.1"><Trackpoint/><Trackpoint/></gpx>`), gpx.MaxPoints(1))
+	assert.Nil(t, err)
+}
+
+func Test_ParseTCXWithoutPositions(t *testing.T) {
+	g, err := gpx.ParseTCX(strings.NewReader(`<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+  <Activities>
+    <Activity Sport="Biking">
+      <Id>2020-01-01T10:00:00Z</Id>
+      <Lap StartTime="2020-01-01T10:00:00Z">
+        <Track>
+          <Trackpoint><Time>2020-01-01T10:00:00Z</Time><HeartRateBpm><Value>120</Value></HeartRateBpm></Trackpoint>
+          <Trackpoint><Time>2020-01-01T10:00:01Z</Time><Cadence>90</Cadence></Trackpoint>
+        </Track>
+      </Lap>
+      <Lap StartTime="2020-01-01T10:01:00Z">
+        <Track>
+          <Trackpoint><Time>2020-01-01T10:01:00Z</Time></Trackpoint>
+          <Trackpoint><Time>2020-01-01T10:01:01Z</Time><Position><LatitudeDegrees>47.5</LatitudeDegrees><LongitudeDegrees>-122.5</LongitudeDegrees></Position></Trackpoint>
+          <Trackpoint><Time>2020-01-01T10:01:02Z</Time></Trackpoint>
+        </Track>
+      </Lap>
+    </Activity>
+    <Activity Sport="Running">
+      <Id>2020-01-02T10:00:00Z</Id>
+      <Lap StartTime="2020-01-02T10:00:00Z">
+        <Track>
+          <Trackpoint><Time>2020-01-02T10:00:00Z</Time><HeartRateBpm><Value>150</Value></HeartRateBpm></Trackpoint>
+        </Track>
+      </Lap>
+    </Activity>
+  </Activities>
+</TrainingCenterDatabase>`))
+	require.Nil(t, err)
+	require.Len(t, g.Tracks, 2)
+
+	// Points before the first position get it, and the others the last one before them
+	segments := g.Tracks[0].TrackSegments
+	require.Len(t, segments, 2)
+	require.Len(t, segments[0].TrackPoint, 2)
+	require.Len(t, segments[1].TrackPoint, 3)
+	for _, segment := range segments {
+		for _, point := range segment.TrackPoint {
+			assert.Equal(t, gpx.Latitude(47.5), point.Latitude)
+			assert.Equal(t, gpx.Longitude(-122.5), point.Longitude)
+		}
+	}
+	assert.Equal(t, gpx.BeatsPerMinute(120), segments[0].TrackPoint[0].Extensions.TrackPointExtensions.HeartRate)
+	assert.Equal(t, gpx.RevolutionsPerMinute(90), segments[0].TrackPoint[1].Extensions.TrackPointExtensions.Cadence)
+	assert.Equal(t, "2020-01-01T10:01:02Z", segments[1].TrackPoint[2].Timestamp.String())
+
+	// Indoor activities have no position at all
+	points := g.Tracks[1].TrackSegments[0].TrackPoint
+	require.Len(t, points, 1)
+	assert.Equal(t, gpx.Latitude(0), points[0].Latitude)
+	assert.Equal(t, gpx.BeatsPerMinute(150), points[0].Extensions.TrackPointExtensions.HeartRate)
+}
+
+func Test_WriteTCX(t *testing.T) {
+	start := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	first := gpx.TrackSegment{TrackPoint: []gpx.TrackPoint{
+		withSensors(trackPoint(0, 0, 10, start), 100, 170, 0),
+		withSensors(trackPoint(0, 0.001, 12, start.Add(30*time.Second)), 110, 172, 0),
+	}}
+	speed := gpx.MetresPerSecond(3.7)
+	first.TrackPoint[1].Extensions.TrackPointExtensions.Speed = &speed
+	first.TrackPoint[1].Extensions.Power = 300
+	second := gpx.TrackSegment{TrackPoint: []gpx.TrackPoint{
+		trackPoint(0, 0.002, 12, start.Add(5*time.Minute)),
+		trackPoint(0, 0.003, 0, start.Add(6*time.Minute)),
+	}}
+	g := &gpx.GPX{Tracks: []gpx.Track{
+		{Type: "running", Description: "Intervals", TrackSegments: []gpx.TrackSegment{first, {}, second}},
+		{Name: "Empty", TrackSegments: []gpx.TrackSegment{{}}},
+	}}
+
+	buf := bytes.Buffer{}
+	require.Nil(t, gpx.WriteTCX(&buf, g))
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, out, `xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2"`)
+	assert.Contains(t, out, `<Activity Sport="Running">`)
+	assert.Contains(t, out, `<Id>2020-01-01T10:00:00Z</Id>`)
+	assert.Contains(t, out, `<Lap StartTime="2020-01-01T10:05:00Z">`)
+	assert.Contains(t, out, `<ax:RunCadence>172</ax:RunCadence>`)
+	assert.Contains(t, out, `<ax:Watts>300</ax:Watts>`)
+	assert.Equal(t, 2, strings.Count(out, "<Lap "))
+	assert.Equal(t, 1, strings.Count(out, "<Activity "))
+	assert.Contains(t, out, "<AltitudeMeters>0</AltitudeMeters>")
+	assert.Contains(t, out, "<AverageHeartRateBpm>\n                    <Value>105</Value>")
+
+	p, err := gpx.ParseTCX(&buf)
+	require.Nil(t, err)
+	require.Len(t, p.Tracks, 1)
+	assert.Equal(t, "Running", p.Tracks[0].Type)
+	assert.Equal(t, "Intervals", p.Tracks[0].Description)
+	require.Len(t, p.Tracks[0].TrackSegments, 2)
+	for i, point := range p.Tracks[0].TrackSegments[0].TrackPoint {
+		assert.Equal(t, first.TrackPoint[i].Longitude, point.Longitude)
+		assert.Equal(t, first.TrackPoint[i].Elevation, point.Elevation)
+		assert.True(t, first.TrackPoint[i].Timestamp.Equal(point.Timestamp.Time))
+		assert.Equal(t, first.TrackPoint[i].Extensions, point.Extensions)
+	}
+	assert.Equal(t, second.TrackPoint[1].Longitude, p.Tracks[0].TrackSegments[1].TrackPoint[1].Longitude)
+
+	// Trackpoints, laps and activities need a time
+	second.TrackPoint[1].Timestamp = gpx.DateTime{}
+	buf.Reset()
+	err = gpx.WriteTCX(&buf, g)
+	assert.True(t, errors.Is(err, gpx.ErrUntimedPoint))
+	assert.Zero(t, buf.Len())
+}
@@ -0,0 +1,84 @@
+package gpx
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	xml "github.com/Zauberstuhl/go-xml"
+)
+
+// dateTimeLayouts are the xsd:dateTime variants found in GPX files, with and without
+// fractional seconds and time zone
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// DateTime is an xsd:dateTime as used for every time element in GPX.
+// It remembers the text it was parsed from, so that values which have not been
+// changed are written back with their original precision and time zone.
+// Values without a time zone are read as UTC.
+type DateTime struct {
+	time.Time
+	raw    string
+	parsed time.Time
+}
+
+// NewDateTime wraps t into a DateTime
+func NewDateTime(t time.Time) DateTime {
+	return DateTime{Time: t}
+}
+
+// ParseDateTime parses an xsd:dateTime
+func ParseDateTime(value string) (DateTime, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateTimeLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return DateTime{Time: t, raw: value, parsed: t}, nil
+		}
+	}
+	return DateTime{}, fmt.Errorf("gpx: invalid dateTime %q, expected a format like 2006-01-02T15:04:05Z", value)
+}
+
+// String returns the original text if the time hasn't been changed since it was parsed,
+// and RFC3339 otherwise
+func (d DateTime) String() string {
+	if d.raw != "" && d.Time.Equal(d.parsed) && d.Time.Location() == d.parsed.Location() {
+		return d.raw
+	}
+	if d.Time.IsZero() {
+		return ""
+	}
+	return d.Time.Format(time.RFC3339Nano)
+}
+
+// MarshalXML writes the time as an element, or nothing at all if it is zero
+func (d DateTime) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	value := d.String()
+	if value == "" {
+		return nil
+	}
+	return e.EncodeElement(value, start)
+}
+
+// UnmarshalXML reads the time from an element
+func (d *DateTime) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	var value string
+	if err := dec.DecodeElement(&value, &start); err != nil {
+		return err
+	}
+	if strings.TrimSpace(value) == "" {
+		*d = DateTime{}
+		return nil
+	}
+
+	parsed, err := ParseDateTime(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,69 @@
+package gpx_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_ParseDateTime(t *testing.T) {
+	cases := map[string]time.Time{
+		"2018-02-26T22:58:34Z":          time.Date(2018, 2, 26, 22, 58, 34, 0, time.UTC),
+		"2012-10-24T23:29:40.000Z":      time.Date(2012, 10, 24, 23, 29, 40, 0, time.UTC),
+		"2012-10-24T23:29:40.125Z":      time.Date(2012, 10, 24, 23, 29, 40, 125000000, time.UTC),
+		"2008-02-26T19:49:13":           time.Date(2008, 2, 26, 19, 49, 13, 0, time.UTC),
+		"2018-02-26T22:58:34+05:30":     time.Date(2018, 2, 26, 17, 28, 34, 0, time.UTC),
+		"2018-02-26T22:58:34.5-0100":    time.Date(2018, 2, 26, 23, 58, 34, 500000000, time.UTC),
+		" 2018-02-26T22:58:34.123456Z ": time.Date(2018, 2, 26, 22, 58, 34, 123456000, time.UTC),
+	}
+
+	for value, expected := range cases {
+		d, err := gpx.ParseDateTime(value)
+		require.Nil(t, err, value)
+		assert.True(t, expected.Equal(d.Time), value)
+	}
+
+	_, err := gpx.ParseDateTime("yesterday")
+	assert.Contains(t, err.Error(), `"yesterday"`)
+}
+
+func Test_DateTimeFromFile(t *testing.T) {
+	g, err := gpx.ParseFile("./samples/mapbox.gpx")
+	require.Nil(t, err)
+
+	point0 := g.Tracks[0].TrackSegments[0].TrackPoint[0]
+	point1 := g.Tracks[0].TrackSegments[0].TrackPoint[1]
+	assert.Equal(t, time.Date(2012, 10, 24, 23, 29, 40, 0, time.UTC), point0.Timestamp.Time)
+	assert.Equal(t, 20*time.Second, point1.Timestamp.Sub(point0.Timestamp.Time))
+}
+
+func Test_DateTimeRoundTrip(t *testing.T) {
+	g := gpx.GPX{}
+	err := gpx.Parse([]byte(`<gpx version="1.1"><metadata><time>2008-02-26T19:49:13</time></metadata>
+		<wpt lat="1" lon="2"><time>2018-02-26T22:58:34.500+05:30</time></wpt>
+		<wpt lat="1" lon="2"><time>2018-02-26T22:58:34Z</time></wpt>
+		<wpt lat="1" lon="2"></wpt></gpx>`), &g)
+	require.Nil(t, err)
+
+	g.Waypoints[1].Timestamp = gpx.NewDateTime(g.Waypoints[1].Timestamp.Add(time.Second))
+
+	buf := bytes.Buffer{}
+	encoder := gpx.NewEncoder(&buf)
+	require.Nil(t, encoder.Encode(&g))
+
+	out := buf.String()
+	assert.Contains(t, out, "<time>2008-02-26T19:49:13</time>")
+	assert.Contains(t, out, "<time>2018-02-26T22:58:34.500+05:30</time>")
+	assert.Contains(t, out, "<time>2018-02-26T22:58:35Z</time>")
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("<time>")))
+}
+
+func Test_InvalidDateTime(t *testing.T) {
+	g := gpx.GPX{}
+	err := gpx.Parse([]byte(`<gpx version="1.1"><wpt lat="1" lon="2"><time>26/02/2018</time></wpt></gpx>`), &g)
+	assert.Contains(t, err.Error(), "invalid dateTime")
+}
@@ -0,0 +1,570 @@
+package gpx
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	xml "github.com/Zauberstuhl/go-xml"
+)
+
+// IssueKind tells what is wrong with a part of a document
+type IssueKind int
+
+const (
+	// InvalidValue is a value outside the type or range allowed by the schema
+	InvalidValue IssueKind = iota + 1
+	// MissingAttribute is a required attribute which isn't there
+	MissingAttribute
+	// UnexpectedElement is an element which isn't allowed where it was found
+	UnexpectedElement
+)
+
+// Issue is a single place where a document doesn't follow the schema
+type Issue struct {
+	Kind IssueKind
+	// Path locates the element, like /gpx/trk[1]/trkseg[1]/trkpt[3]/ele, with /@name
+	// appended for attributes. Indexes start at 1 and are only given to elements
+	// which can be repeated.
+	Path string
+	// Line and Column of the start tag of the element, starting at 1
+	Line    int
+	Column  int
+	Message string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Path, i.Message)
+}
+
+// ValidationError is returned when decoding with the Strict option a document which
+// doesn't follow the schema
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Issues) == 0 {
+		return "gpx: invalid document"
+	}
+	message := "gpx: invalid document: " + e.Issues[0].String()
+	if len(e.Issues) > 1 {
+		message += fmt.Sprintf(" (and %d more issues)", len(e.Issues)-1)
+	}
+	return message
+}
+
+// Validate checks a document against the GPX 1.1 schema and the schemas of the Garmin
+// extensions listed in garmin.go, and returns every issue found. The error is only
+// set when the document can't be read at all, like for malformed XML.
+// Elements from other namespaces inside extensions are not checked. GPX 1.0 documents
+// only have their values checked, not their structure.
+func Validate(r io.Reader) ([]Issue, error) {
+	t := newTracker(r, gpxDialect)
+	v := newValidator(t, t)
+	for {
+		if _, err := v.Token(); err != nil {
+			if err == io.EOF {
+				return v.issues, nil
+			}
+			return v.issues, v.tracker.wrap(err)
+		}
+	}
+}
+
+// valueCheck returns what a value was expected to be when it is not valid, and ""
+// otherwise
+type valueCheck func(string) string
+
+// elementType holds the constraints of the schema on an element
+type elementType struct {
+	attrs    map[string]valueCheck
+	required []string
+	text     valueCheck
+	// children are the elements allowed inside, in the order of the schema sequence
+	children []child
+	// any allows elements from other namespaces as children
+	any bool
+	// repeated elements get an index in their path
+	repeated bool
+}
+
+// child is an element allowed inside another one, at most max times, with 0 meaning
+// no limit
+type child struct {
+	name string
+	max  int
+}
+
+// child returns the position of an element in the sequence of children
+func (t *elementType) child(name string) (int, bool) {
+	for i, c := range t.children {
+		if c.name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// frame is an element being validated. Elements which aren't checked, like those
+// from unknown namespaces, have no type.
+type frame struct {
+	typ    *elementType
+	path   string
+	counts map[string]int
+	// last is the position in the sequence of the last child found
+	last   int
+	text   strings.Builder
+	line   int
+	column int
+}
+
+// validator passes tokens through while checking them against the schema
+type validator struct {
+	tokens    xml.TokenReader
+	tracker   *tracker
+	stack     []*frame
+	issues    []Issue
+	version10 bool
+}
+
+// newValidator checks the tokens read from tokens, which come from t through any
+// other readers
+func newValidator(tokens xml.TokenReader, t *tracker) *validator {
+	return &validator{tokens: tokens, tracker: t}
+}
+
+// Token returns the next token after checking it
+func (v *validator) Token() (xml.Token, error) {
+	token, err := v.tokens.Token()
+	if err != nil {
+		return token, err
+	}
+
+	switch t := token.(type) {
+	case xml.StartElement:
+		v.start(t)
+	case xml.CharData:
+		if len(v.stack) > 0 {
+			if current := v.stack[len(v.stack)-1]; current.typ != nil && current.typ.text != nil {
+				current.text.Write(t)
+			}
+		}
+	case xml.EndElement:
+		v.end()
+	}
+	return token, nil
+}
+
+// take returns the issues found so far and forgets them
+func (v *validator) take() []Issue {
+	issues := v.issues
+	v.issues = nil
+	return issues
+}
+
+func (v *validator) start(t xml.StartElement) {
+	name := t.Name.Local
+	element, _ := v.tracker.current()
+	current := &frame{counts: map[string]int{}, line: element.line, column: element.column}
+
+	var parent *frame
+	if len(v.stack) > 0 {
+		parent = v.stack[len(v.stack)-1]
+	}
+	v.stack = append(v.stack, current)
+
+	if parent == nil {
+		current.path = "/" + name
+		if name != "gpx" {
+			v.report(current, UnexpectedElement, current.path, "root element is "+name+", expected gpx")
+			return
+		}
+		v.version10 = isVersion10(t)
+	} else {
+		if parent.typ == nil {
+			return
+		}
+		parent.counts[name]++
+		current.path = parent.path + "/" + name
+	}
+
+	typ := v.lookup(name)
+	if typ != nil && typ.repeated {
+		current.path += "[" + strconv.Itoa(parent.counts[name]) + "]"
+	}
+
+	position, known := 0, false
+	if parent != nil {
+		position, known = parent.typ.child(name)
+	}
+	switch {
+	case parent == nil:
+	case known:
+		if !v.version10 {
+			v.checkSequence(parent, current, name, position)
+		}
+	case parent.typ.any:
+		// extensions are only checked when they are known
+		if typ == nil || !strings.Contains(name, ":") {
+			return
+		}
+	case v.version10:
+		if typ == nil {
+			return
+		}
+	default:
+		v.report(current, UnexpectedElement, current.path, fmt.Sprintf("unexpected element %s", name))
+		return
+	}
+	current.typ = typ
+
+	present := map[string]bool{}
+	for _, attr := range t.Attr {
+		if attr.Name.Space != "" {
+			continue
+		}
+		present[attr.Name.Local] = true
+		if check := typ.attrs[attr.Name.Local]; check != nil {
+			if expected := check(attr.Value); expected != "" {
+				v.report(current, InvalidValue, current.path+"/@"+attr.Name.Local, fmt.Sprintf("invalid value %q, expected %s", attr.Value, expected))
+			}
+		}
+	}
+	for _, required := range typ.required {
+		if !present[required] {
+			v.report(current, MissingAttribute, current.path, "missing required attribute "+required)
+		}
+	}
+}
+
+func (v *validator) end() {
+	if len(v.stack) == 0 {
+		return
+	}
+	current := v.stack[len(v.stack)-1]
+	v.stack = v.stack[:len(v.stack)-1]
+
+	if current.typ == nil || current.typ.text == nil {
+		return
+	}
+	value := strings.TrimSpace(current.text.String())
+	if expected := current.typ.text(value); expected != "" {
+		v.report(current, InvalidValue, current.path, fmt.Sprintf("invalid value %q, expected %s", value, expected))
+	}
+}
+
+// checkSequence checks that a child comes in the order of the schema sequence of its
+// parent, and isn't repeated more than allowed
+func (v *validator) checkSequence(parent, current *frame, name string, position int) {
+	if position < parent.last {
+		previous := parent.typ.children[parent.last].name
+		v.report(current, UnexpectedElement, current.path, fmt.Sprintf("unexpected element %s after %s", name, previous))
+	} else {
+		parent.last = position
+	}
+	if limit := parent.typ.children[position].max; limit > 0 && parent.counts[name] > limit {
+		message := fmt.Sprintf("unexpected element %s, only %d allowed", name, limit)
+		if limit == 1 {
+			message = fmt.Sprintf("unexpected element %s, only one allowed", name)
+		}
+		v.report(current, UnexpectedElement, current.path, message)
+	}
+}
+
+func (v *validator) report(f *frame, kind IssueKind, path, message string) {
+	v.issues = append(v.issues, Issue{Kind: kind, Path: path, Line: f.line, Column: f.column, Message: message})
+}
+
+// lookup returns the type of an element from GPX or a known extension
+func (v *validator) lookup(name string) *elementType {
+	if v.version10 {
+		if typ, ok := schema10[name]; ok {
+			return typ
+		}
+	}
+	return schema[name]
+}
+
+// sequence returns the children of a type in order. Names ending with * can be
+// repeated without limit, and names ending with a number in braces, like name{2}, up
+// to that number of times. Other children are allowed once.
+func sequence(list ...string) []child {
+	children := make([]child, 0, len(list))
+	for _, name := range list {
+		c := child{name: name, max: 1}
+		if trimmed, ok := strings.CutSuffix(name, "*"); ok {
+			c = child{name: trimmed}
+		} else if trimmed, limit, ok := strings.Cut(name, "{"); ok {
+			c.name = trimmed
+			c.max, _ = strconv.Atoi(strings.TrimSuffix(limit, "}"))
+		}
+		children = append(children, c)
+	}
+	return children
+}
+
+var (
+	decimalPattern = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	integerPattern = regexp.MustCompile(`^[+-]?\d+$`)
+	gYearPattern   = regexp.MustCompile(`^-?\d{4,}(Z|[+-]\d{2}:\d{2})?$`)
+	hexPattern     = regexp.MustCompile(`^([0-9a-fA-F]{2})*$`)
+)
+
+func isString(string) string {
+	return ""
+}
+
+func isDecimal(value string) string {
+	if !decimalPattern.MatchString(value) {
+		return "a decimal number"
+	}
+	return ""
+}
+
+func isDouble(value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return "a number"
+	}
+	return ""
+}
+
+func isSpeed(value string) string {
+	if f, err := strconv.ParseFloat(value, 64); err != nil || f < 0 {
+		return "a positive number"
+	}
+	return ""
+}
+
+func isDateTime(value string) string {
+	if _, err := ParseDateTime(value); err != nil {
+		return "a date and time like 2006-01-02T15:04:05Z"
+	}
+	return ""
+}
+
+func isYear(value string) string {
+	if !gYearPattern.MatchString(value) {
+		return "a year"
+	}
+	return ""
+}
+
+func isBoolean(value string) string {
+	switch value {
+	case "true", "false", "1", "0":
+		return ""
+	}
+	return "true or false"
+}
+
+func isHex(value string) string {
+	if !hexPattern.MatchString(value) {
+		return "hexadecimal bytes"
+	}
+	return ""
+}
+
+// decimalBetween checks a decimal number from min up to max, which is only allowed
+// when inclusive is set
+func decimalBetween(min, max float64, inclusive bool) valueCheck {
+	bound := "up to"
+	if inclusive {
+		bound = "to"
+	}
+	expected := fmt.Sprintf("a decimal number from %g %s %g", min, bound, max)
+	if !inclusive {
+		expected += " excluded"
+	}
+	return func(value string) string {
+		if !decimalPattern.MatchString(value) {
+			return expected
+		}
+		f, _ := strconv.ParseFloat(value, 64)
+		if f < min || f > max || (!inclusive && f == max) {
+			return expected
+		}
+		return ""
+	}
+}
+
+// integerBetween checks an integer from min to max, with max < 0 meaning no maximum
+func integerBetween(min, max int64) valueCheck {
+	expected := fmt.Sprintf("an integer from %d to %d", min, max)
+	if max < 0 {
+		expected = fmt.Sprintf("an integer of at least %d", min)
+	}
+	return func(value string) string {
+		if !integerPattern.MatchString(value) {
+			return expected
+		}
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || i < min || (max >= 0 && i > max) {
+			return expected
+		}
+		return ""
+	}
+}
+
+func oneOf(values ...string) valueCheck {
+	expected := "one of " + strings.Join(values, ", ")
+	return func(value string) string {
+		for _, allowed := range values {
+			if value == allowed {
+				return ""
+			}
+		}
+		return expected
+	}
+}
+
+var (
+	isLatitude           = decimalBetween(-90, 90, true)
+	isLongitude          = decimalBetween(-180, 180, false)
+	isDegrees            = decimalBetween(0, 360, false)
+	isNonNegativeInteger = integerBetween(0, -1)
+
+	isFix = oneOf(string(None), string(TwoDimensional), string(ThreeDimensional), string(DGPS), string(PPS))
+
+	isDisplayMode  = oneOf(string(SymbolOnly), string(SymbolAndName), string(SymbolAndDescription))
+	isDisplayColor = oneOf(string(Black), string(DarkRed), string(DarkGreen), string(DarkYellow), string(DarkBlue),
+		string(DarkMagenta), string(DarkCyan), string(LightGray), string(DarkGray), string(Red), string(Green),
+		string(Yellow), string(Blue), string(Magenta), string(Cyan), string(White), string(Transparent))
+)
+
+// pointType is wptType, used by wpt, rtept and trkpt
+var pointType = &elementType{
+	attrs:    map[string]valueCheck{"lat": isLatitude, "lon": isLongitude},
+	required: []string{"lat", "lon"},
+	children: sequence("ele", "time", "magvar", "geoidheight", "name", "cmt", "desc", "src", "link*", "sym", "type",
+		"fix", "sat", "hdop", "vdop", "pdop", "ageofdgpsdata", "dgpsid", "extensions"),
+	repeated: true,
+}
+
+// schema holds the types of the elements from GPX 1.1 and the known extensions.
+// Element names are unique enough in these schemas for the type to only depend on
+// the name.
+var schema = map[string]*elementType{
+	"gpx": {
+		attrs:    map[string]valueCheck{"version": oneOf("1.1", "1.0")},
+		required: []string{"version", "creator"},
+		children: sequence("metadata", "wpt*", "rte*", "trk*", "extensions"),
+	},
+	"metadata": {children: sequence("name", "desc", "author", "copyright", "link*", "time", "keywords", "bounds", "extensions")},
+	"wpt":      pointType,
+	"rtept":    pointType,
+	"trkpt":    pointType,
+	"rte": {
+		children: sequence("name", "cmt", "desc", "src", "link*", "number", "type", "extensions", "rtept*"),
+		repeated: true,
+	},
+	"trk": {
+		children: sequence("name", "cmt", "desc", "src", "link*", "number", "type", "extensions", "trkseg*"),
+		repeated: true,
+	},
+	"trkseg":     {children: sequence("trkpt*", "extensions"), repeated: true},
+	"extensions": {any: true},
+	"author":     {children: sequence("name", "email", "link")},
+	"copyright": {
+		required: []string{"author"},
+		children: sequence("year", "license"),
+	},
+	"link": {
+		required: []string{"href"},
+		children: sequence("text", "type"),
+		repeated: true,
+	},
+	"email": {required: []string{"id", "domain"}},
+	"ptseg": {children: sequence("pt*")},
+	"pt": {
+		attrs:    map[string]valueCheck{"lat": isLatitude, "lon": isLongitude},
+		required: []string{"lat", "lon"},
+		children: sequence("ele", "time"),
+		repeated: true,
+	},
+	"bounds": {
+		attrs:    map[string]valueCheck{"minlat": isLatitude, "minlon": isLongitude, "maxlat": isLatitude, "maxlon": isLongitude},
+		required: []string{"minlat", "minlon", "maxlat", "maxlon"},
+	},
+
+	"name":          {text: isString},
+	"desc":          {text: isString},
+	"cmt":           {text: isString},
+	"src":           {text: isString},
+	"sym":           {text: isString},
+	"type":          {text: isString},
+	"text":          {text: isString},
+	"keywords":      {text: isString},
+	"license":       {text: isString},
+	"ele":           {text: isDecimal},
+	"geoidheight":   {text: isDecimal},
+	"hdop":          {text: isDecimal},
+	"vdop":          {text: isDecimal},
+	"pdop":          {text: isDecimal},
+	"ageofdgpsdata": {text: isDecimal},
+	"time":          {text: isDateTime},
+	"magvar":        {text: isDegrees},
+	"fix":           {text: isFix},
+	"sat":           {text: isNonNegativeInteger},
+	"number":        {text: isNonNegativeInteger},
+	"dgpsid":        {text: integerBetween(0, 1023)},
+	"year":          {text: isYear},
+
+	"gpxx:WaypointExtension": {children: sequence("gpxx:Proximity", "gpxx:Temperature", "gpxx:Depth", "gpxx:DisplayMode",
+		"gpxx:Categories", "gpxx:Address", "gpxx:PhoneNumber*", "gpxx:Samples", "gpxx:Expiration", "gpxx:Extensions")},
+	"gpxx:Proximity":   {text: isDouble},
+	"gpxx:Temperature": {text: isDouble},
+	"gpxx:Depth":       {text: isDouble},
+	"gpxx:DisplayMode": {text: isDisplayMode},
+	"gpxx:Categories":  {children: sequence("gpxx:Category*")},
+	"gpxx:Category":    {text: isString, repeated: true},
+	"gpxx:Address": {children: sequence("gpxx:StreetAddress{2}", "gpxx:City", "gpxx:State", "gpxx:Country",
+		"gpxx:PostalCode", "gpxx:Extensions")},
+	"gpxx:StreetAddress":       {text: isString, repeated: true},
+	"gpxx:City":                {text: isString},
+	"gpxx:State":               {text: isString},
+	"gpxx:Country":             {text: isString},
+	"gpxx:PostalCode":          {text: isString},
+	"gpxx:PhoneNumber":         {text: isString, repeated: true},
+	"gpxx:Samples":             {text: isNonNegativeInteger},
+	"gpxx:Expiration":          {text: isDateTime},
+	"gpxx:RouteExtension":      {children: sequence("gpxx:IsAutoNamed", "gpxx:DisplayColor", "gpxx:Extensions")},
+	"gpxx:IsAutoNamed":         {text: isBoolean},
+	"gpxx:DisplayColor":        {text: isDisplayColor},
+	"gpxx:RoutePointExtension": {children: sequence("gpxx:Subclass", "gpxx:rpt*", "gpxx:Extensions")},
+	"gpxx:Subclass":            {text: isHex},
+	"gpxx:rpt": {
+		attrs:    map[string]valueCheck{"lat": isLatitude, "lon": isLongitude},
+		required: []string{"lat", "lon"},
+		children: sequence("gpxx:Subclass"),
+		repeated: true,
+	},
+	"gpxx:TrackExtension": {children: sequence("gpxx:DisplayColor", "gpxx:Extensions")},
+	"gpxx:Extensions":     {any: true},
+
+	"gpxtpx:TrackPointExtension": {children: sequence("gpxtpx:atemp", "gpxtpx:wtemp", "gpxtpx:depth", "gpxtpx:hr",
+		"gpxtpx:cad", "gpxtpx:speed", "gpxtpx:course", "gpxtpx:bearing", "gpxtpx:Extensions")},
+	"gpxtpx:atemp":      {text: isDouble},
+	"gpxtpx:wtemp":      {text: isDouble},
+	"gpxtpx:depth":      {text: isDouble},
+	"gpxtpx:hr":         {text: integerBetween(1, 255)},
+	"gpxtpx:cad":        {text: integerBetween(0, 254)},
+	"gpxtpx:speed":      {text: isSpeed},
+	"gpxtpx:course":     {text: isDegrees},
+	"gpxtpx:bearing":    {text: isDegrees},
+	"gpxtpx:Extensions": {any: true},
+
+	"pwr:PowerInWatts": {text: isNonNegativeInteger},
+}
+
+// schema10 holds the types of GPX 1.0 elements which differ from GPX 1.1
+var schema10 = map[string]*elementType{
+	"author":  {text: isString},
+	"email":   {text: isString},
+	"url":     {text: isString},
+	"urlname": {text: isString},
+	"course":  {text: isDegrees},
+	"speed":   {text: isDecimal},
+}
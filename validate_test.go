@@ -0,0 +1,114 @@
+package gpx_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gpx "github.com/sudhanshuraheja/go-garmin-gpx"
+)
+
+func Test_Validate(t *testing.T) {
+	file, err := os.Open("./samples/invalid.gpx")
+	require.Nil(t, err)
+	defer file.Close()
+
+	issues, err := gpx.Validate(file)
+	require.Nil(t, err)
+
+	expected := []gpx.Issue{
+		{Kind: gpx.InvalidValue, Path: "/gpx/wpt[1]/@lat", Line: 3, Column: 3},
+		{Kind: gpx.InvalidValue, Path: "/gpx/wpt[1]/fix", Line: 4, Column: 5},
+		{Kind: gpx.InvalidValue, Path: "/gpx/wpt[1]/dgpsid", Line: 5, Column: 5},
+		{Kind: gpx.InvalidValue, Path: "/gpx/trk[1]/extensions/gpxx:TrackExtension/gpxx:DisplayColor", Line: 11, Column: 9},
+		{Kind: gpx.InvalidValue, Path: "/gpx/trk[1]/trkseg[1]/trkpt[1]/magvar", Line: 16, Column: 9},
+		{Kind: gpx.MissingAttribute, Path: "/gpx/trk[1]/trkseg[1]/trkpt[2]", Line: 18, Column: 7},
+		{Kind: gpx.UnexpectedElement, Path: "/gpx/trk[1]/trkseg[1]/trkpt[2]/speed", Line: 19, Column: 9},
+		{Kind: gpx.InvalidValue, Path: "/gpx/trk[1]/trkseg[1]/trkpt[2]/extensions/gpxtpx:TrackPointExtension/gpxtpx:hr", Line: 22, Column: 13},
+	}
+	require.Len(t, issues, len(expected))
+	for i := range expected {
+		expected[i].Message = issues[i].Message
+		assert.Equal(t, expected[i], issues[i])
+	}
+	assert.Equal(t, `invalid value "500", expected a decimal number from -90 to 90`, issues[0].Message)
+	assert.Equal(t, "missing required attribute lat", issues[5].Message)
+}
+
+func Test_ValidateSamples(t *testing.T) {
+	files := []string{"strava-1427712053", "mapbox", "extensions-ns3", "trackpointextension-v2", "unknown-extensions", "gpx10"}
+	for _, name := range files {
+		file, err := os.Open("./samples/" + name + ".gpx")
+		require.Nil(t, err)
+
+		issues, err := gpx.Validate(file)
+		file.Close()
+		require.Nil(t, err)
+		assert.Empty(t, issues, name)
+	}
+}
+
+func Test_ValidateStructure(t *testing.T) {
+	issues, err := gpx.Validate(strings.NewReader(`<gpx version="1.1" creator="test">
+  <trk>
+    <name>Morning</name>
+    <name>Ride</name>
+  </trk>
+  <trk>
+    <trkseg/>
+    <name>Evening</name>
+  </trk>
+  <trk>
+    <name>Night</name>
+    <link href="a"/>
+    <link href="b"/>
+    <trkseg/>
+    <trkseg/>
+  </trk>
+</gpx>`))
+	require.Nil(t, err)
+
+	require.Len(t, issues, 2)
+	assert.Equal(t, gpx.Issue{Kind: gpx.UnexpectedElement, Path: "/gpx/trk[1]/name", Line: 4, Column: 5,
+		Message: "unexpected element name, only one allowed"}, issues[0])
+	assert.Equal(t, gpx.Issue{Kind: gpx.UnexpectedElement, Path: "/gpx/trk[2]/name", Line: 8, Column: 5,
+		Message: "unexpected element name after trkseg"}, issues[1])
+}
+
+func Test_StrictParse(t *testing.T) {
+	_, err := gpx.ParseFile("./samples/invalid.gpx")
+	require.Nil(t, err)
+
+	_, err = gpx.ParseFile("./samples/invalid.gpx", gpx.Strict())
+	validation := &gpx.ValidationError{}
+	require.True(t, errors.As(err, &validation))
+	assert.Len(t, validation.Issues, 8)
+	assert.Equal(t, `gpx: invalid document: 3:3: /gpx/wpt[1]/@lat: invalid value "500", expected a decimal number from -90 to 90 (and 7 more issues)`, err.Error())
+
+	_, err = gpx.ParseFile("./samples/strava-1427712053.gpx", gpx.Strict())
+	require.Nil(t, err)
+
+	assert.Equal(t, "gpx: invalid document", (&gpx.ValidationError{}).Error())
+}
+
+func Test_StrictStream(t *testing.T) {
+	file, err := os.Open("./samples/invalid.gpx")
+	require.Nil(t, err)
+	defer file.Close()
+
+	decoder := gpx.NewDecoder(file, gpx.Strict())
+	elements := 0
+	for _, err := range decoder.Elements() {
+		if err != nil {
+			validation := &gpx.ValidationError{}
+			require.True(t, errors.As(err, &validation))
+			assert.Equal(t, "/gpx/wpt[1]/@lat", validation.Issues[0].Path)
+			break
+		}
+		elements++
+	}
+	assert.Equal(t, 0, elements)
+}